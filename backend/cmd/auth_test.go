@@ -6,35 +6,34 @@ import (
 	"linkshortener/internal/auth"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
-
-	"github.com/joho/godotenv"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
-func initDb() *gorm.DB {
-	godotenv.Load()
-	db, err := gorm.Open(postgres.Open(os.Getenv("DB_DSN")), &gorm.Config{})
-	if err != nil {
-		panic("Error connecting to database")
-	}
-	return db
+// Приложение целиком на хранилищах в памяти: у каждого теста свои пустые данные и не нужна БД
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Setenv("DB_DRIVER", "memory")
+	t.Setenv("SECRET_KEY", "test-access-secret")
+	t.Setenv("REFRESH_SECRET_KEY", "test-refresh-secret")
+
+	ts := httptest.NewServer(appInit())
+	t.Cleanup(ts.Close)
+	return ts
 }
 
-func removeDbData(db *gorm.DB) {
-	db.Exec("DELETE FROM links")
-	db.Exec("DELETE FROM stats")
-	db.Exec("DELETE FROM users")
+func register(t *testing.T, ts *httptest.Server, request auth.RegisterRequest) {
+	data, _ := json.Marshal(&request)
+	res, err := http.Post(ts.URL+"/auth/register", "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status Created, got %d", res.StatusCode)
+	}
 }
 
 func TestRegisterSuccess(t *testing.T) {
-	db := initDb()
-	defer removeDbData(db)
-
-	ts := httptest.NewServer(appInit())
-	defer ts.Close()
+	ts := newTestServer(t)
 
 	data, _ := json.Marshal(&auth.RegisterRequest{
 		Email:    "test@test.com",
@@ -68,11 +67,7 @@ type testCase struct {
 }
 
 func TestRegisterFailed(t *testing.T) {
-	db := initDb()
-	defer removeDbData(db)
-
-	ts := httptest.NewServer(appInit())
-	defer ts.Close()
+	ts := newTestServer(t)
 
 	testCases := []testCase{
 		{
@@ -116,11 +111,12 @@ func TestRegisterFailed(t *testing.T) {
 }
 
 func TestLoginSuccess(t *testing.T) {
-	db := initDb()
-	defer removeDbData(db)
-
-	ts := httptest.NewServer(appInit())
-	defer ts.Close()
+	ts := newTestServer(t)
+	register(t, ts, auth.RegisterRequest{
+		Email:    "test@test.com",
+		Password: "password123!",
+		Name:     "test",
+	})
 
 	data, _ := json.Marshal(&auth.LoginRequest{
 		Email:    "test@test.com",
@@ -152,11 +148,7 @@ func TestLoginSuccess(t *testing.T) {
 }
 
 func TestLoginFailed(t *testing.T) {
-	db := initDb()
-	defer removeDbData(db)
-
-	ts := httptest.NewServer(appInit())
-	defer ts.Close()
+	ts := newTestServer(t)
 
 	data, _ := json.Marshal(&auth.LoginRequest{
 		Email:    "test@test.com",
@@ -1,77 +1,361 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"linkshortener/config"
+	"linkshortener/internal/admin"
+	"linkshortener/internal/audit"
 	"linkshortener/internal/auth"
 	"linkshortener/internal/link"
 	"linkshortener/internal/stats"
-	"linkshortener/internal/user"
-	"linkshortener/migrations"
+	"linkshortener/pkg/di"
 	"linkshortener/pkg/event"
+	"linkshortener/pkg/health"
 	"linkshortener/pkg/jwt"
+	"linkshortener/pkg/logger"
+	"linkshortener/pkg/metrics"
 	"linkshortener/pkg/middleware"
+	"linkshortener/pkg/pgnotify"
+	"linkshortener/pkg/ratelimit"
+	"linkshortener/pkg/tracing"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+type App struct {
+	Handler http.Handler
+	Health  *health.Checker
+
+	storage      *storage
+	eventBus     *event.EventBus
+	eventBridge  *pgnotify.Bridge
+	statsService *stats.StatsService
+	// Очистка корзин лимитов в Postgres; nil для остальных хранилищ
+	rateLimitSweeper *ratelimit.PostgresStore
+}
+
 func appInit() http.Handler {
-	config, err := config.LoadConfig()
+	cfg, err := config.LoadConfig()
 	if err != nil {
 		panic(err)
 	}
 
-	database := migrations.RunMigrations(config)
+	app, err := newApp(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return app.Handler
+}
+
+func newApp(cfg *config.Config) (*App, error) {
+	slog.SetDefault(logger.New(os.Stdout, cfg.Log.Format, cfg.Log.Level))
 
-	linkRepository := link.NewLinkRepository(database)
-	userRepository := user.NewUserRepository(database)
-	statsRepository := stats.NewStatsRepository(database)
+	storage, err := newStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	linkRepository := storage.links
+	userRepository := storage.users
+	statsRepository := storage.stats
+	auditRepository := storage.audit
 	eventBus := event.NewEventBus()
 
+	var cachedLinks *link.CachedLinkRepository
+	if cfg.Link.Cache.Enabled {
+		var linkCache di.ILinkCache[link.Link] = link.NewMemoryLinkCache(cfg.Link.Cache.Size)
+		if cfg.Link.Cache.Store == config.StoreRedis {
+			linkCache = link.NewRedisLinkCache(storage.redis)
+		}
+		cachedLinks = link.NewCachedLinkRepository(storage.links, linkCache, cfg.Link.Cache)
+		eventBus.On(event.LinkCreated, cachedLinks.HandleEvent)
+		eventBus.On(event.LinkUpdated, cachedLinks.HandleEvent)
+		eventBus.On(event.LinkDeleted, cachedLinks.HandleEvent)
+		linkRepository = cachedLinks
+	}
+
+	// Изменения ссылок сбрасывают кэши редиректов на всех инстансах
+	var eventBridge *pgnotify.Bridge
+	if cfg.Events.Bridge == config.EventsBridgePostgres {
+		sqlDB, err := storage.database.DB.DB()
+		if err != nil {
+			return nil, err
+		}
+		eventBridge = pgnotify.NewBridge(eventBus, sqlDB, cfg.DB.URL, cfg.Events.Channel)
+		pgnotify.Forward[event.LinkChanged](eventBridge, event.LinkCreated)
+		pgnotify.Forward[event.LinkChanged](eventBridge, event.LinkUpdated)
+		pgnotify.Forward[event.LinkChanged](eventBridge, event.LinkDeleted)
+		if cachedLinks != nil {
+			eventBridge.OnResync(cachedLinks.InvalidateAll)
+		}
+	}
+
+	metrics.RegisterEventBusDepth(eventBus.Len)
+	if storage.database != nil {
+		if sqlDB, err := storage.database.DB.DB(); err == nil {
+			metrics.RegisterDBStats(sqlDB)
+		}
+	}
+
+	if err := userRepository.PromoteAdmins(context.Background(), cfg.Admin.Emails); err != nil {
+		return nil, err
+	}
+
 	// services
-	authService := auth.NewAuthService(userRepository,
-		jwt.NewJWT(config.Auth.SecretKey, config.Auth.RefreshTokenSecretKey))
+	jwtService := jwt.NewJWT(cfg.Auth.SecretKey, cfg.Auth.RefreshTokenSecretKey)
+	authService := auth.NewAuthService(userRepository, jwtService)
+	oidcService := auth.NewOIDCService(userRepository, jwtService, cfg.OIDC.Providers)
 
+	var clickBuffer di.IClickBuffer = stats.NewMemoryClickBuffer()
+	if cfg.Stats.ClickBuffer == config.StoreRedis {
+		clickBuffer = stats.NewRedisClickBuffer(storage.redis)
+	}
 	statsService := stats.NewStatsService(&stats.StatsServiceDeps{
 		EventBus:        eventBus,
 		StatsRepository: statsRepository,
+		ClickBuffer:     clickBuffer,
+	})
+
+	auditService := audit.NewAuditService(&audit.AuditServiceDeps{
+		AuditRepository: auditRepository,
+	})
+
+	healthChecker := health.NewChecker(cfg.Server.HealthCheckTimeout)
+	if storage.database != nil {
+		healthChecker.Add("database", func(ctx context.Context) error {
+			sqlDB, err := storage.database.DB.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		})
+		healthChecker.Add("migrations", storage.migrator.Check)
+	}
+	if storage.redis != nil {
+		healthChecker.Add("redis", func(ctx context.Context) error {
+			return storage.redis.Ping(ctx).Err()
+		})
+	}
+	healthChecker.Add("event_consumers", func(ctx context.Context) error {
+		if !statsService.Running() {
+			return errors.New("stats consumer is not running")
+		}
+		return nil
 	})
 
 	router := http.NewServeMux()
+	router.Handle("GET /metrics", promhttp.Handler())
+	health.NewHealthHandler(router, &health.HealthHandlerDeps{
+		Checker: healthChecker,
+	})
 
 	// handlers
 	auth.NewAuthHandler(router, &auth.AuthHandlerDeps{
+		Config:      cfg,
 		AuthService: authService,
+		OIDCService: oidcService,
+		AuditLogger: auditService,
 	})
 
 	link.NewLinkHandler(router, &link.LinkHandlerDeps{
-		Config:         config,
+		Config:         cfg,
 		LinkRepository: linkRepository,
+		UserRepository: userRepository,
 		EventBus:       eventBus,
+		AuditLogger:    auditService,
 	})
 
 	stats.NewStatsHandler(router, &stats.StatsHandlerDeps{
-		Config:          config,
+		Config:          cfg,
 		StatsRepository: statsRepository,
+		UserRepository:  userRepository,
+		UserLinks:       linkRepository,
+	})
+
+	admin.NewAdminHandler(router, &admin.AdminHandlerDeps{
+		Config:         cfg,
+		UserRepository: userRepository,
+		LinkRepository: linkRepository,
+		CodeGenerator:  storage.codes,
+		EventBus:       eventBus,
+		AuditLogger:    auditService,
+	})
+
+	audit.NewAuditHandler(router, &audit.AuditHandlerDeps{
+		Config:          cfg,
+		AuditRepository: auditRepository,
+		UserRepository:  userRepository,
 	})
 
 	// middlewares
-	stack := middleware.Chain(
-		middleware.Cors,
+	middlewares := []middleware.Middleware{
+		middleware.RequestID,
+		middleware.RealIP(cfg.Server.TrustedProxies),
 		middleware.LogRequest,
-	)
+		middleware.Metrics,
+		middleware.Tracing,
+		middleware.Recover(nil),
+		middleware.Cors(middleware.NewCorsPolicy(cfg), middleware.NewRedirectCorsRoute(cfg)),
+	}
+	var rateLimitSweeper *ratelimit.PostgresStore
+	if cfg.RateLimit.Enabled {
+		var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+		switch cfg.RateLimit.Store {
+		case config.StorePostgres:
+			postgresStore := ratelimit.NewPostgresStore(storage.database, cfg.RateLimit.RefillTime())
+			postgresStore.Start()
+			rateLimitStore, rateLimitSweeper = postgresStore, postgresStore
+		case config.StoreRedis:
+			rateLimitStore = ratelimit.NewRedisStore(storage.redis)
+		}
+		middlewares = append(middlewares, middleware.RateLimit(rateLimitStore, cfg.RateLimit.Policies, jwtService))
+	}
+	stack := middleware.Chain(middlewares...)
 	go statsService.AddClick()
+	if eventBridge != nil {
+		eventBridge.Start()
+	}
+
+	return &App{
+		Handler:      stack(middleware.Route(router)),
+		Health:       healthChecker,
+		storage:      storage,
+		eventBus:     eventBus,
+		eventBridge:  eventBridge,
+		statsService: statsService,
 
-	return stack(router)
+		rateLimitSweeper: rateLimitSweeper,
+	}, nil
+}
+
+// Останавливает фоновую работу после того, как HTTP-сервер перестал принимать запросы:
+// отключает мост событий, закрывает шину, ждёт записи накопленных кликов и закрывает пул соединений с БД
+func (app *App) Shutdown(ctx context.Context) error {
+	if app.eventBridge != nil {
+		app.eventBridge.Close()
+	}
+	app.eventBus.Close()
+	if app.rateLimitSweeper != nil {
+		app.rateLimitSweeper.Close()
+	}
+	if err := app.statsService.Wait(ctx); err != nil {
+		return fmt.Errorf("stats consumer: %w", err)
+	}
+	return app.storage.Close()
 }
 
 func main() {
-	appInit := appInit()
+	config, options, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if options.PrintConfig {
+		if err := config.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(options.Args) > 0 && options.Args[0] == "migrate" {
+		slog.SetDefault(logger.New(os.Stderr, config.Log.Format, config.Log.Level))
+		if err := runMigrate(context.Background(), config, options.Args[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(options.Args) > 0 {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", options.Args[0])
+		os.Exit(2)
+	}
+
+	// Логгер нужен уже для ошибок инициализации трассировки; newApp настраивает его так же
+	slog.SetDefault(logger.New(os.Stdout, config.Log.Format, config.Log.Level))
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		Exporter:    config.Tracing.Exporter,
+		ServiceName: config.Tracing.ServiceName,
+		SampleRatio: config.Tracing.SampleRatio,
+	})
+	if err != nil {
+		slog.Error("failed to init tracing", "error", err)
+		os.Exit(1)
+	}
+
+	app, err := newApp(config)
+	if err != nil {
+		slog.Error("failed to start", "error", err)
+		shutdownTracing(context.Background())
+		os.Exit(1)
+	}
 
 	server := &http.Server{
-		Addr:    ":8081",
-		Handler: appInit,
+		Addr:              fmt.Sprintf(":%d", config.Server.Port),
+		Handler:           app.Handler,
+		ReadHeaderTimeout: config.Server.ReadHeaderTimeout,
+		ReadTimeout:       config.Server.ReadTimeout,
+		WriteTimeout:      config.Server.WriteTimeout,
+		IdleTimeout:       config.Server.IdleTimeout,
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		slog.Info("server is running", "addr", server.Addr)
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	exitCode := 0
+	select {
+	case err := <-serverErrors:
+		slog.Error("server stopped", "error", err)
+		exitCode = 1
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	}
+
+	// Порядок важен: сначала снимаем инстанс с балансировки и дожидаемся текущих запросов,
+	// затем дописываем события, и только потом закрываем БД и экспорт трасс
+	app.Health.SetShuttingDown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Балансировщику нужно несколько проверок readiness, чтобы снять инстанс; до этого
+	// новые запросы ещё приходят и должны обслуживаться, а не получать отказ в соединении
+	select {
+	case <-time.After(config.Server.ShutdownDelay):
+	case <-shutdownCtx.Done():
+	}
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server shutdown failed", "error", err)
+		exitCode = 1
+	}
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		slog.Error("app shutdown failed", "error", err)
+		exitCode = 1
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing shutdown failed", "error", err)
+		exitCode = 1
 	}
 
-	fmt.Println("Server is running on port 8081")
-	server.ListenAndServe()
+	slog.Info("server stopped")
+	os.Exit(exitCode)
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"linkshortener/config"
+	"linkshortener/internal/audit"
+	"linkshortener/internal/link"
+	"linkshortener/internal/stats"
+	"linkshortener/internal/user"
+	"linkshortener/migrations"
+	"linkshortener/pkg/db"
+	"linkshortener/pkg/di"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Репозитории выбранного драйвера БД. Для драйвера memory database и migrator равны nil,
+// redis — nil, если ни одно хранилище не вынесено в Redis
+type storage struct {
+	database *db.Db
+	migrator *migrations.Migrator
+	redis    redis.UniversalClient
+
+	codes link.CodeGenerator
+	links di.ILinkRepository[link.Link]
+	users di.IUserRepository
+	stats di.IStatsRepository[stats.StatsResponse]
+	audit di.IAuditRepository[audit.AuditLog, audit.AuditFilter]
+}
+
+func newStorage(cfg *config.Config) (*storage, error) {
+	var redisClient redis.UniversalClient
+	if cfg.UsesRedis() {
+		options, err := redis.ParseURL(cfg.Redis.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+		redisClient = redis.NewClient(options)
+	}
+
+	if cfg.DB.Driver == config.DBDriverMemory {
+		codes, err := link.NewCodeGenerator(cfg.Link, link.NewMemoryCodeCounter())
+		if err != nil {
+			return nil, err
+		}
+		return &storage{
+			redis: redisClient,
+			codes: codes,
+			links: link.NewMemoryLinkRepository(codes),
+			users: user.NewMemoryUserRepository(),
+			stats: stats.NewMemoryStatsRepository(),
+			audit: audit.NewMemoryAuditRepository(),
+		}, nil
+	}
+
+	database, err := migrations.Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := migrations.NewMigrator(database)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.Ensure(context.Background(), cfg.DB.AutoMigrate); err != nil {
+		return nil, err
+	}
+
+	codes, err := link.NewCodeGenerator(cfg.Link, link.NewCodeCounterRepository(database))
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage{
+		database: database,
+		migrator: migrator,
+		redis:    redisClient,
+		codes:    codes,
+		links:    link.NewLinkRepository(database, codes, cfg.Link.CodePoolSize),
+		users:    user.NewUserRepository(database),
+		stats:    stats.NewStatsRepository(database),
+		audit:    audit.NewAuditRepository(database),
+	}, nil
+}
+
+func (s *storage) Close() error {
+	if links, ok := s.links.(*link.LinkRepository); ok {
+		links.Close()
+	}
+
+	var errs []error
+	if s.redis != nil {
+		errs = append(errs, s.redis.Close())
+	}
+	if s.database != nil {
+		sqlDB, err := s.database.DB.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -1,35 +1,366 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const EnvProduction = "production"
+
+const (
+	DBDriverPostgres = "postgres"
+	// Встроенный SQLite (без cgo): весь сервис в одном бинарнике и одном файле БД
+	DBDriverSQLite = "sqlite"
+	// Хранилища в памяти процесса: данные теряются при остановке, подходит для разработки и тестов
+	DBDriverMemory = "memory"
+)
+
+const (
+	EventsBridgeNone     = "none"
+	EventsBridgePostgres = "postgres"
+)
+
+// Где хранить кэш редиректов, счётчики ограничений и буфер кликов
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+	StoreRedis    = "redis"
+)
+
+const (
+	CodeStrategyRandom        = "random"
+	CodeStrategyCounter       = "counter"
+	CodeStrategyPronounceable = "pronounceable"
+	CodeStrategySequential    = "sequential"
+)
+
 type Config struct {
-	DB   DbConfig
-	Auth AuthConfig
+	Env       string          `yaml:"env" toml:"env"`
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	DB        DbConfig        `yaml:"db" toml:"db"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Admin     AdminConfig     `yaml:"admin" toml:"admin"`
+	OIDC      OIDCConfig      `yaml:"oidc" toml:"oidc"`
+	Cors      CorsConfig      `yaml:"cors" toml:"cors"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Link      LinkConfig      `yaml:"link" toml:"link"`
+	Events    EventsConfig    `yaml:"events" toml:"events"`
+	Stats     StatsConfig     `yaml:"stats" toml:"stats"`
+	Redis     RedisConfig     `yaml:"redis" toml:"redis"`
+}
+
+type ServerConfig struct {
+	Port               int           `yaml:"port" toml:"port"`
+	ReadHeaderTimeout  time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout        time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout       time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout        time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	TrustedProxies     []string      `yaml:"trusted_proxies" toml:"trusted_proxies"`
+	HealthCheckTimeout time.Duration `yaml:"health_check_timeout" toml:"health_check_timeout"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	ShutdownDelay      time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay"`
+}
+
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format"`
+	Level  string `yaml:"level" toml:"level"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
 }
 
 type DbConfig struct {
-	URL string
+	Driver          string        `yaml:"driver" toml:"driver"`
+	URL             string        `yaml:"url" toml:"url"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	QueryTimeout    time.Duration `yaml:"query_timeout" toml:"query_timeout"`
+	ReportTimeout   time.Duration `yaml:"report_timeout" toml:"report_timeout"`
+	// Кэш подготовленных выражений GORM: каждый запрос готовится один раз на соединение
+	PrepareStmt bool `yaml:"prepare_stmt" toml:"prepare_stmt"`
+	// Реплики только для чтения: на них уходят запросы вне транзакций, запись — на основную БД
+	ReplicaURLs []string `yaml:"replica_urls" toml:"replica_urls"`
+	// Сколько после записи чтения того же пользователя идут на основную БД
+	ReadYourWritesWindow time.Duration `yaml:"read_your_writes_window" toml:"read_your_writes_window"`
+	// Применять миграции при старте; иначе сервис не запустится на устаревшей схеме
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
 }
 
 type AuthConfig struct {
-	SecretKey             string
-	RefreshTokenSecretKey string
+	SecretKey             string       `yaml:"secret_key" toml:"secret_key"`
+	RefreshTokenSecretKey string       `yaml:"refresh_secret_key" toml:"refresh_secret_key"`
+	Cookie                CookieConfig `yaml:"cookie" toml:"cookie"`
 }
 
-func LoadConfig() (*Config, error) {
-	godotenv.Load()
+type CookieConfig struct {
+	Enabled  bool   `yaml:"enabled" toml:"enabled"`
+	Domain   string `yaml:"domain" toml:"domain"`
+	Secure   bool   `yaml:"secure" toml:"secure"`
+	SameSite string `yaml:"same_site" toml:"same_site"`
+}
+
+type AdminConfig struct {
+	Emails []string `yaml:"emails" toml:"emails"`
+}
+
+type CorsConfig struct {
+	AllowedOrigins         []string `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowedMethods         []string `yaml:"allowed_methods" toml:"allowed_methods"`
+	AllowedHeaders         []string `yaml:"allowed_headers" toml:"allowed_headers"`
+	ExposedHeaders         []string `yaml:"exposed_headers" toml:"exposed_headers"`
+	MaxAge                 int      `yaml:"max_age" toml:"max_age"`
+	AllowCredentials       bool     `yaml:"allow_credentials" toml:"allow_credentials"`
+	RedirectAllowedOrigins []string `yaml:"redirect_allowed_origins" toml:"redirect_allowed_origins"`
+}
+
+type OIDCConfig struct {
+	Providers          []OIDCProviderConfig `yaml:"providers" toml:"providers"`
+	SuccessRedirectURL string               `yaml:"success_redirect_url" toml:"success_redirect_url"`
+}
+
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name" toml:"name"`
+	IssuerURL    string   `yaml:"issuer_url" toml:"issuer_url"`
+	ClientID     string   `yaml:"client_id" toml:"client_id"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url"`
+	Scopes       []string `yaml:"scopes" toml:"scopes"`
+}
+
+type LinkConfig struct {
+	HashLength int `yaml:"hash_length" toml:"hash_length"`
+	// Сколько заранее проверенных свободных hash держать в памяти; 0 — генерировать при создании ссылки
+	CodePoolSize int             `yaml:"code_pool_size" toml:"code_pool_size"`
+	Code         LinkCodeConfig  `yaml:"code" toml:"code"`
+	Cache        LinkCacheConfig `yaml:"cache" toml:"cache"`
+}
+
+// Стратегия генерации коротких кодов: random, counter, pronounceable или sequential — одна на инстанс,
+// рабочих пространств со своей стратегией нет. Alphabet не используется стратегией pronounceable,
+// Salt — только стратегией counter
+type LinkCodeConfig struct {
+	Strategy string `yaml:"strategy" toml:"strategy"`
+	Alphabet string `yaml:"alphabet" toml:"alphabet"`
+	Salt     string `yaml:"salt" toml:"salt"`
+}
+
+// Кэш редиректов: ссылки по hash, в том числе отсутствующие (NegativeTTL, 0 — не кэшировать)
+type LinkCacheConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// memory (LRU на каждом инстансе, размер Size) или redis (общий для всех инстансов)
+	Store       string        `yaml:"store" toml:"store"`
+	Size        int           `yaml:"size" toml:"size"`
+	TTL         time.Duration `yaml:"ttl" toml:"ttl"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" toml:"negative_ttl"`
+}
+
+// Передача событий шины между инстансами: none или postgres (LISTEN/NOTIFY в канале Channel)
+type EventsConfig struct {
+	Bridge  string `yaml:"bridge" toml:"bridge"`
+	Channel string `yaml:"channel" toml:"channel"`
+}
+
+type StatsConfig struct {
+	// Очередь кликов перед записью в БД: memory или redis (переживает перезапуск инстанса)
+	ClickBuffer string `yaml:"click_buffer" toml:"click_buffer"`
+}
+
+type RedisConfig struct {
+	URL string `yaml:"url" toml:"url"`
+}
+
+// Хотя бы одно хранилище вынесено в Redis
+func (c *Config) UsesRedis() bool {
+	return (c.Link.Cache.Enabled && c.Link.Cache.Store == StoreRedis) ||
+		(c.RateLimit.Enabled && c.RateLimit.Store == StoreRedis) ||
+		c.Stats.ClickBuffer == StoreRedis
+}
+
+// Параметры запуска, которые не являются настройками сервиса
+type Options struct {
+	ConfigFile  string
+	PrintConfig bool
+	// Аргументы после флагов, например подкоманда
+	Args []string
+}
+
+func Default() *Config {
+	policies, err := ParseRateLimitPolicies(DefaultRateLimitPolicies)
+	if err != nil {
+		panic(err)
+	}
 
 	return &Config{
+		Env: "development",
+		Server: ServerConfig{
+			Port:               8081,
+			ReadHeaderTimeout:  5 * time.Second,
+			ReadTimeout:        15 * time.Second,
+			WriteTimeout:       15 * time.Second,
+			IdleTimeout:        time.Minute,
+			TrustedProxies:     []string{"127.0.0.1/32", "::1/128"},
+			HealthCheckTimeout: 2 * time.Second,
+			ShutdownTimeout:    15 * time.Second,
+			ShutdownDelay:      5 * time.Second,
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "linkshortener",
+			SampleRatio: 1,
+		},
 		DB: DbConfig{
-			URL: os.Getenv("DB_URL"),
+			Driver:               DBDriverPostgres,
+			ConnectTimeout:       time.Minute,
+			MaxOpenConns:         25,
+			MaxIdleConns:         25,
+			ConnMaxLifetime:      30 * time.Minute,
+			ConnMaxIdleTime:      5 * time.Minute,
+			QueryTimeout:         5 * time.Second,
+			ReportTimeout:        30 * time.Second,
+			ReadYourWritesWindow: 5 * time.Second,
 		},
 		Auth: AuthConfig{
-			SecretKey:             os.Getenv("SECRET_KEY"),
-			RefreshTokenSecretKey: os.Getenv("REFRESH_SECRET_KEY"),
+			Cookie: CookieConfig{
+				Secure:   true,
+				SameSite: "lax",
+			},
+		},
+		Cors: CorsConfig{
+			AllowedOrigins:         []string{"http://localhost:3000", "http://localhost:8080"},
+			AllowedMethods:         []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
+			AllowedHeaders:         []string{"Content-Type", "Authorization", "X-Requested-With", "X-Refresh-Token", "X-CSRF-Token", "X-Request-ID"},
+			ExposedHeaders:         []string{"X-New-Access-Token", "X-New-Refresh-Token", "X-Request-ID"},
+			MaxAge:                 86400,
+			AllowCredentials:       true,
+			RedirectAllowedOrigins: []string{"*"},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:  true,
+			Store:    StoreMemory,
+			Policies: policies,
+		},
+		Link: LinkConfig{
+			HashLength:   12,
+			CodePoolSize: 1000,
+			Code: LinkCodeConfig{
+				Strategy: CodeStrategyRandom,
+				Alphabet: "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+			},
+			Cache: LinkCacheConfig{
+				Enabled:     true,
+				Store:       StoreMemory,
+				Size:        10000,
+				TTL:         5 * time.Minute,
+				NegativeTTL: 10 * time.Second,
+			},
+		},
+		Events: EventsConfig{
+			Bridge:  EventsBridgeNone,
+			Channel: "linkshortener_events",
 		},
-	}, nil
+		Stats: StatsConfig{
+			ClickBuffer: StoreMemory,
+		},
+	}
+}
+
+// Собирает конфигурацию по слоям: значения по умолчанию, файл (YAML или TOML),
+// переменные окружения и флаги командной строки. Каждый следующий слой важнее предыдущего.
+func Load(args []string) (*Config, *Options, error) {
+	godotenv.Load()
+
+	options := &Options{ConfigFile: os.Getenv("CONFIG_FILE")}
+	config := Default()
+
+	flags := flag.NewFlagSet("linkshortener", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.StringVar(&options.ConfigFile, "config", options.ConfigFile, "path to YAML or TOML config file")
+	flags.BoolVar(&options.PrintConfig, "print-config", false, "print effective config with secrets redacted and exit")
+
+	// Флаги разбираются первыми (нужен путь к файлу), но применяются последними
+	flagValues := make(map[string]*rawFlag)
+	for _, s := range settings(Default()) {
+		if name := s.flagName(); name != "" {
+			flagValues[name] = &rawFlag{isBool: isBoolValue(s.value)}
+			flags.Var(flagValues[name], name, "overrides "+s.env)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	options.Args = flags.Args()
+
+	if options.ConfigFile != "" {
+		if err := loadFile(config, options.ConfigFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := applyEnv(config); err != nil {
+		return nil, nil, err
+	}
+
+	if err := applyFlags(config, flagValues); err != nil {
+		return nil, nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return config, options, nil
+}
+
+// Загрузка без флагов командной строки
+func LoadConfig() (*Config, error) {
+	config, _, err := Load(nil)
+	return config, err
+}
+
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Значение переменной окружения или содержимое файла из <NAME>_FILE (для секретов в Docker/Kubernetes)
+func lookupEnv(name string) (string, bool, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+
+	path, ok := os.LookupEnv(name + "_FILE")
+	if !ok || path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, errors.New(name + "_FILE: " + err.Error())
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
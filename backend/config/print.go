@@ -0,0 +1,75 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// Копия конфигурации без секретов: ключей, соли кодов, паролей в DSN и client secret провайдеров
+func (c *Config) Redacted() *Config {
+	copied := *c
+	copied.Auth.SecretKey = redactValue(c.Auth.SecretKey)
+	copied.Auth.RefreshTokenSecretKey = redactValue(c.Auth.RefreshTokenSecretKey)
+	copied.Link.Code.Salt = redactValue(c.Link.Code.Salt)
+	// Путь к файлу SQLite секретом не является
+	if c.DB.Driver != DBDriverSQLite {
+		copied.DB.URL = redactURL(c.DB.URL)
+	}
+	copied.Redis.URL = redactURL(c.Redis.URL)
+	copied.DB.ReplicaURLs = make([]string, len(c.DB.ReplicaURLs))
+	for i, replicaURL := range c.DB.ReplicaURLs {
+		copied.DB.ReplicaURLs[i] = redactURL(replicaURL)
+	}
+
+	copied.OIDC.Providers = make([]OIDCProviderConfig, len(c.OIDC.Providers))
+	for i, provider := range c.OIDC.Providers {
+		provider.ClientSecret = redactValue(provider.ClientSecret)
+		copied.OIDC.Providers[i] = provider
+	}
+	return &copied
+}
+
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func redactValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
+
+func redactURL(value string) string {
+	// DSN в формате "host=... password=..." не разбирается как URL и скрывается целиком
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" {
+		return redactValue(value)
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+	}
+	// Пароль можно передать и параметром: postgres://app@host/db?password=...
+	// Порядок и запись остальных параметров сохраняются
+	params := strings.Split(parsed.RawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && secretQueryParams[strings.ToLower(name)] {
+			params[i] = key + "=" + redacted
+		}
+	}
+	parsed.RawQuery = strings.Join(params, "&")
+	return parsed.String()
+}
+
+var secretQueryParams = map[string]bool{"password": true, "sslpassword": true, "pass": true}
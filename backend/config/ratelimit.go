@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyUser   = "user"
+	RateLimitKeyAPIKey = "api_key"
+)
+
+const DefaultRateLimitPolicies = "POST /auth/login=ip:5/1m:5;POST /auth/register=ip:5/1m:5;POST /link=user:30/1m:10"
+
+type RateLimitConfig struct {
+	Enabled  bool              `yaml:"enabled" toml:"enabled"`
+	Store    string            `yaml:"store" toml:"store"`
+	Policies []RateLimitPolicy `yaml:"policies" toml:"policies"`
+}
+
+type RateLimitPolicy struct {
+	Method     string        `yaml:"method" toml:"method"`
+	PathPrefix string        `yaml:"path_prefix" toml:"path_prefix"`
+	Key        string        `yaml:"key" toml:"key"`
+	Requests   int           `yaml:"requests" toml:"requests"`
+	Period     time.Duration `yaml:"period" toml:"period"`
+	Burst      int           `yaml:"burst" toml:"burst"`
+}
+
+// Время, за которое опустевшая корзина самой медленной политики пополняется целиком
+func (c RateLimitConfig) RefillTime() time.Duration {
+	var refill time.Duration
+	for _, policy := range c.Policies {
+		refill = max(refill, policy.Period*time.Duration(policy.Burst)/time.Duration(policy.Requests))
+	}
+	return refill
+}
+
+// Формат: "<METHOD> <path>=<key>:<requests>/<period>:<burst>", политики разделяются ";".
+// Метод "*" совпадает с любым методом, например "* /link=ip:100/1s:200"
+func ParseRateLimitPolicies(value string) ([]RateLimitPolicy, error) {
+	var policies []RateLimitPolicy
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		policy, err := parseRateLimitPolicy(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit policy %q: %w", item, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func parseRateLimitPolicy(item string) (RateLimitPolicy, error) {
+	var policy RateLimitPolicy
+
+	route, limit, ok := strings.Cut(item, "=")
+	if !ok {
+		return policy, fmt.Errorf("missing '='")
+	}
+
+	method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+	if !ok {
+		return policy, fmt.Errorf("route must be '<METHOD> <path>'")
+	}
+	policy.Method = strings.ToUpper(method)
+	policy.PathPrefix = strings.TrimSpace(path)
+
+	parts := strings.Split(strings.TrimSpace(limit), ":")
+	if len(parts) != 3 {
+		return policy, fmt.Errorf("limit must be '<key>:<requests>/<period>:<burst>'")
+	}
+
+	policy.Key = parts[0]
+	if policy.Key != RateLimitKeyIP && policy.Key != RateLimitKeyUser && policy.Key != RateLimitKeyAPIKey {
+		return policy, fmt.Errorf("unknown key %q", policy.Key)
+	}
+
+	requests, period, ok := strings.Cut(parts[1], "/")
+	if !ok {
+		return policy, fmt.Errorf("rate must be '<requests>/<period>'")
+	}
+
+	var err error
+	if policy.Requests, err = strconv.Atoi(requests); err != nil || policy.Requests <= 0 {
+		return policy, fmt.Errorf("invalid requests %q", requests)
+	}
+	if policy.Period, err = time.ParseDuration(period); err != nil || policy.Period <= 0 {
+		return policy, fmt.Errorf("invalid period %q", period)
+	}
+	if policy.Burst, err = strconv.Atoi(parts[2]); err != nil || policy.Burst <= 0 {
+		return policy, fmt.Errorf("invalid burst %q", parts[2])
+	}
+
+	return policy, nil
+}
+
+// Обратное преобразование в формат RATE_LIMIT_POLICIES
+func FormatRateLimitPolicies(policies []RateLimitPolicy) string {
+	items := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		items = append(items, fmt.Sprintf("%s %s=%s:%d/%s:%d",
+			policy.Method, policy.PathPrefix, policy.Key, policy.Requests, policy.Period, policy.Burst))
+	}
+	return strings.Join(items, ";")
+}
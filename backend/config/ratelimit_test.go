@@ -0,0 +1,46 @@
+package config_test
+
+import (
+	"linkshortener/config"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitPolicies(t *testing.T) {
+	policies, err := config.ParseRateLimitPolicies("POST /auth/login=ip:5/1m:5; * /link=user:100/1s:200")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(policies) != 2 {
+		t.Fatalf("Expected 2 policies, got %d", len(policies))
+	}
+
+	expected := config.RateLimitPolicy{
+		Method:     "*",
+		PathPrefix: "/link",
+		Key:        config.RateLimitKeyUser,
+		Requests:   100,
+		Period:     time.Second,
+		Burst:      200,
+	}
+	if policies[1] != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, policies[1])
+	}
+}
+
+func TestParseRateLimitPoliciesInvalid(t *testing.T) {
+	invalid := []string{
+		"POST /auth/login",
+		"/auth/login=ip:5/1m:5",
+		"POST /auth/login=session:5/1m:5",
+		"POST /auth/login=ip:5/minute:5",
+		"POST /auth/login=ip:0/1m:5",
+	}
+
+	for _, value := range invalid {
+		if _, err := config.ParseRateLimitPolicies(value); err == nil {
+			t.Fatalf("Expected error for %q", value)
+		}
+	}
+}
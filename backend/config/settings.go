@@ -0,0 +1,296 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Настройка, которую можно задать переменной окружения и (кроме секретов) флагом командной строки
+type setting struct {
+	env    string
+	value  settingValue
+	secret bool
+}
+
+type settingValue interface {
+	String() string
+	Set(string) error
+}
+
+// Флаг называется по переменной окружения: DB_MAX_OPEN_CONNS -> --db-max-open-conns.
+// Секреты флагами не задаются, чтобы они не попадали в список процессов.
+func (s setting) flagName() string {
+	if s.secret {
+		return ""
+	}
+	return strings.ToLower(strings.ReplaceAll(s.env, "_", "-"))
+}
+
+func settings(c *Config) []setting {
+	return []setting{
+		{env: "APP_ENV", value: stringValue(&c.Env)},
+
+		{env: "PORT", value: intValue(&c.Server.Port)},
+		{env: "HTTP_READ_HEADER_TIMEOUT", value: durationValue(&c.Server.ReadHeaderTimeout)},
+		{env: "HTTP_READ_TIMEOUT", value: durationValue(&c.Server.ReadTimeout)},
+		{env: "HTTP_WRITE_TIMEOUT", value: durationValue(&c.Server.WriteTimeout)},
+		{env: "HTTP_IDLE_TIMEOUT", value: durationValue(&c.Server.IdleTimeout)},
+		{env: "TRUSTED_PROXIES", value: listValue(&c.Server.TrustedProxies)},
+		{env: "HEALTH_CHECK_TIMEOUT", value: durationValue(&c.Server.HealthCheckTimeout)},
+		{env: "SHUTDOWN_TIMEOUT", value: durationValue(&c.Server.ShutdownTimeout)},
+		{env: "SHUTDOWN_DELAY", value: durationValue(&c.Server.ShutdownDelay)},
+
+		{env: "LOG_FORMAT", value: stringValue(&c.Log.Format)},
+		{env: "LOG_LEVEL", value: stringValue(&c.Log.Level)},
+
+		{env: "TRACING_EXPORTER", value: stringValue(&c.Tracing.Exporter)},
+		{env: "OTEL_SERVICE_NAME", value: stringValue(&c.Tracing.ServiceName)},
+		{env: "TRACING_SAMPLE_RATIO", value: floatValue(&c.Tracing.SampleRatio)},
+
+		{env: "DB_DRIVER", value: stringValue(&c.DB.Driver)},
+		// DB_DSN — устаревшее имя, DB_URL идёт после и имеет приоритет
+		{env: "DB_DSN", value: stringValue(&c.DB.URL), secret: true},
+		{env: "DB_URL", value: stringValue(&c.DB.URL), secret: true},
+		{env: "DB_CONNECT_TIMEOUT", value: durationValue(&c.DB.ConnectTimeout)},
+		{env: "DB_MAX_OPEN_CONNS", value: intValue(&c.DB.MaxOpenConns)},
+		{env: "DB_MAX_IDLE_CONNS", value: intValue(&c.DB.MaxIdleConns)},
+		{env: "DB_CONN_MAX_LIFETIME", value: durationValue(&c.DB.ConnMaxLifetime)},
+		{env: "DB_CONN_MAX_IDLE_TIME", value: durationValue(&c.DB.ConnMaxIdleTime)},
+		{env: "DB_QUERY_TIMEOUT", value: durationValue(&c.DB.QueryTimeout)},
+		{env: "DB_REPORT_TIMEOUT", value: durationValue(&c.DB.ReportTimeout)},
+		{env: "DB_PREPARE_STMT", value: boolValue(&c.DB.PrepareStmt)},
+		{env: "DB_REPLICA_URLS", value: listValue(&c.DB.ReplicaURLs), secret: true},
+		{env: "DB_READ_YOUR_WRITES_WINDOW", value: durationValue(&c.DB.ReadYourWritesWindow)},
+		{env: "DB_AUTO_MIGRATE", value: boolValue(&c.DB.AutoMigrate)},
+
+		{env: "SECRET_KEY", value: stringValue(&c.Auth.SecretKey), secret: true},
+		{env: "REFRESH_SECRET_KEY", value: stringValue(&c.Auth.RefreshTokenSecretKey), secret: true},
+		{env: "AUTH_COOKIE_MODE", value: boolValue(&c.Auth.Cookie.Enabled)},
+		{env: "AUTH_COOKIE_DOMAIN", value: stringValue(&c.Auth.Cookie.Domain)},
+		{env: "AUTH_COOKIE_INSECURE", value: invertedBoolValue(&c.Auth.Cookie.Secure)},
+		{env: "AUTH_COOKIE_SAMESITE", value: stringValue(&c.Auth.Cookie.SameSite)},
+
+		{env: "ADMIN_EMAILS", value: listValue(&c.Admin.Emails)},
+
+		{env: "OIDC_SUCCESS_REDIRECT_URL", value: stringValue(&c.OIDC.SuccessRedirectURL)},
+
+		{env: "CORS_ALLOWED_ORIGINS", value: listValue(&c.Cors.AllowedOrigins)},
+		{env: "CORS_ALLOWED_METHODS", value: listValue(&c.Cors.AllowedMethods)},
+		{env: "CORS_ALLOWED_HEADERS", value: listValue(&c.Cors.AllowedHeaders)},
+		{env: "CORS_EXPOSED_HEADERS", value: listValue(&c.Cors.ExposedHeaders)},
+		{env: "CORS_MAX_AGE", value: intValue(&c.Cors.MaxAge)},
+		{env: "CORS_ALLOW_CREDENTIALS", value: boolValue(&c.Cors.AllowCredentials)},
+		{env: "CORS_REDIRECT_ALLOWED_ORIGINS", value: listValue(&c.Cors.RedirectAllowedOrigins)},
+
+		{env: "RATE_LIMIT_ENABLED", value: boolValue(&c.RateLimit.Enabled)},
+		{env: "RATE_LIMIT_STORE", value: stringValue(&c.RateLimit.Store)},
+		{env: "RATE_LIMIT_POLICIES", value: &value[[]RateLimitPolicy]{target: &c.RateLimit.Policies, parse: ParseRateLimitPolicies, format: FormatRateLimitPolicies}},
+
+		{env: "LINK_HASH_LENGTH", value: intValue(&c.Link.HashLength)},
+		{env: "LINK_CODE_POOL_SIZE", value: intValue(&c.Link.CodePoolSize)},
+		{env: "LINK_CODE_STRATEGY", value: stringValue(&c.Link.Code.Strategy)},
+		{env: "LINK_CODE_ALPHABET", value: stringValue(&c.Link.Code.Alphabet)},
+		{env: "LINK_CODE_SALT", value: stringValue(&c.Link.Code.Salt), secret: true},
+		{env: "LINK_CACHE_ENABLED", value: boolValue(&c.Link.Cache.Enabled)},
+		{env: "LINK_CACHE_STORE", value: stringValue(&c.Link.Cache.Store)},
+		{env: "LINK_CACHE_SIZE", value: intValue(&c.Link.Cache.Size)},
+		{env: "LINK_CACHE_TTL", value: durationValue(&c.Link.Cache.TTL)},
+		{env: "LINK_CACHE_NEGATIVE_TTL", value: durationValue(&c.Link.Cache.NegativeTTL)},
+
+		{env: "EVENTS_BRIDGE", value: stringValue(&c.Events.Bridge)},
+		{env: "EVENTS_CHANNEL", value: stringValue(&c.Events.Channel)},
+
+		{env: "STATS_CLICK_BUFFER", value: stringValue(&c.Stats.ClickBuffer)},
+
+		{env: "REDIS_URL", value: stringValue(&c.Redis.URL), secret: true},
+	}
+}
+
+func applyEnv(c *Config) error {
+	var errs []error
+	for _, s := range settings(c) {
+		raw, ok, err := lookupEnv(s.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := s.value.Set(raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	}
+
+	if err := applyOIDCEnv(c); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func applyFlags(c *Config, flagValues map[string]*rawFlag) error {
+	var errs []error
+	for _, s := range settings(c) {
+		flagValue, ok := flagValues[s.flagName()]
+		if !ok || !flagValue.set {
+			continue
+		}
+		if err := s.value.Set(flagValue.value); err != nil {
+			errs = append(errs, fmt.Errorf("--%s: %w", s.flagName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Провайдеры перечисляются в OIDC_PROVIDERS, настройки каждого читаются из OIDC_<NAME>_*
+// и дополняют одноимённого провайдера из файла конфигурации
+func applyOIDCEnv(c *Config) error {
+	names, ok := os.LookupEnv("OIDC_PROVIDERS")
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	var providers []OIDCProviderConfig
+	for _, name := range splitList(names) {
+		provider := OIDCProviderConfig{Name: name}
+		for _, existing := range c.OIDC.Providers {
+			if existing.Name == name {
+				provider = existing
+			}
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		fields := []setting{
+			{env: prefix + "ISSUER_URL", value: stringValue(&provider.IssuerURL)},
+			{env: prefix + "CLIENT_ID", value: stringValue(&provider.ClientID)},
+			{env: prefix + "CLIENT_SECRET", value: stringValue(&provider.ClientSecret), secret: true},
+			{env: prefix + "REDIRECT_URL", value: stringValue(&provider.RedirectURL)},
+			{env: prefix + "SCOPES", value: listValue(&provider.Scopes)},
+		}
+		for _, field := range fields {
+			raw, ok, err := lookupEnv(field.env)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if ok {
+				field.value.Set(raw)
+			}
+		}
+		providers = append(providers, provider)
+	}
+	c.OIDC.Providers = providers
+	return errors.Join(errs...)
+}
+
+type value[T any] struct {
+	target *T
+	parse  func(string) (T, error)
+	format func(T) string
+}
+
+func (v *value[T]) Set(raw string) error {
+	parsed, err := v.parse(raw)
+	if err != nil {
+		return err
+	}
+	*v.target = parsed
+	return nil
+}
+
+func (v *value[T]) String() string {
+	if v == nil || v.target == nil {
+		return ""
+	}
+	return v.format(*v.target)
+}
+
+func stringValue(target *string) settingValue {
+	return &value[string]{
+		target: target,
+		parse:  func(raw string) (string, error) { return raw, nil },
+		format: func(v string) string { return v },
+	}
+}
+
+func intValue(target *int) settingValue {
+	return &value[int]{target: target, parse: strconv.Atoi, format: strconv.Itoa}
+}
+
+func floatValue(target *float64) settingValue {
+	return &value[float64]{
+		target: target,
+		parse:  func(raw string) (float64, error) { return strconv.ParseFloat(raw, 64) },
+		format: func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) },
+	}
+}
+
+func durationValue(target *time.Duration) settingValue {
+	return &value[time.Duration]{target: target, parse: time.ParseDuration, format: time.Duration.String}
+}
+
+func listValue(target *[]string) settingValue {
+	return &value[[]string]{
+		target: target,
+		parse:  func(raw string) ([]string, error) { return splitList(raw), nil },
+		format: func(v []string) string { return strings.Join(v, ",") },
+	}
+}
+
+type boolSetting struct {
+	*value[bool]
+}
+
+func (boolSetting) IsBoolFlag() bool {
+	return true
+}
+
+func boolValue(target *bool) settingValue {
+	return boolSetting{&value[bool]{target: target, parse: strconv.ParseBool, format: strconv.FormatBool}}
+}
+
+// AUTH_COOKIE_INSECURE=true выключает флаг Secure у cookie
+func invertedBoolValue(target *bool) settingValue {
+	return boolSetting{&value[bool]{
+		target: target,
+		parse: func(raw string) (bool, error) {
+			insecure, err := strconv.ParseBool(raw)
+			return !insecure, err
+		},
+		format: func(secure bool) string { return strconv.FormatBool(!secure) },
+	}}
+}
+
+func isBoolValue(v settingValue) bool {
+	_, ok := v.(boolSetting)
+	return ok
+}
+
+// Сырое значение флага, которое применяется к конфигурации после окружения
+type rawFlag struct {
+	value  string
+	set    bool
+	isBool bool
+}
+
+func (f *rawFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *rawFlag) Set(raw string) error {
+	f.value = raw
+	f.set = true
+	return nil
+}
+
+func (f *rawFlag) IsBoolFlag() bool {
+	return f.isBool
+}
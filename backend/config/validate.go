@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Максимальная длина hash ограничена размером колонки links.hash
+const maxHashLength = 12
+
+// Проверяет конфигурацию целиком и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(name, value string, allowed ...string) {
+		check(slices.Contains(allowed, value), "%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value)
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout must be positive")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.HealthCheckTimeout > 0, "server.health_check_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.ShutdownDelay >= 0 && c.Server.ShutdownDelay < c.Server.ShutdownTimeout, "server.shutdown_delay must be non-negative and shorter than server.shutdown_timeout")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, prefixErr := netip.ParsePrefix(proxy)
+		_, addrErr := netip.ParseAddr(proxy)
+		check(prefixErr == nil || addrErr == nil, "server.trusted_proxies: %q is not an IP address or CIDR", proxy)
+	}
+
+	oneOf("log.format", c.Log.Format, "json", "text")
+	oneOf("log.level", strings.ToLower(c.Log.Level), "debug", "info", "warn", "error")
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	oneOf("db.driver", c.DB.Driver, DBDriverPostgres, DBDriverSQLite, DBDriverMemory)
+	check(c.DB.URL != "" || c.DB.Driver == DBDriverMemory, "db.url is required (DB_URL)")
+	check(c.DB.ConnectTimeout > 0, "db.connect_timeout must be positive")
+	check(c.DB.MaxOpenConns >= 0, "db.max_open_conns must not be negative")
+	check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns must not be negative")
+	check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "db.max_idle_conns must not exceed db.max_open_conns")
+	check(c.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime must not be negative")
+	check(c.DB.ConnMaxIdleTime >= 0, "db.conn_max_idle_time must not be negative")
+	check(c.DB.QueryTimeout > 0, "db.query_timeout must be positive")
+	check(c.DB.ReportTimeout > 0, "db.report_timeout must be positive")
+	check(len(c.DB.ReplicaURLs) == 0 || c.DB.Driver == DBDriverPostgres, "db.replica_urls requires db.driver=postgres")
+	check(c.DB.ReadYourWritesWindow >= 0, "db.read_your_writes_window must not be negative")
+
+	check(c.Auth.SecretKey != "", "auth.secret_key is required (SECRET_KEY)")
+	check(c.Auth.RefreshTokenSecretKey != "", "auth.refresh_secret_key is required (REFRESH_SECRET_KEY)")
+	check(c.Auth.SecretKey == "" || c.Auth.SecretKey != c.Auth.RefreshTokenSecretKey, "auth.secret_key and auth.refresh_secret_key must differ")
+	if c.IsProduction() {
+		check(len(c.Auth.SecretKey) >= 32, "auth.secret_key must be at least 32 characters in production")
+		check(len(c.Auth.RefreshTokenSecretKey) >= 32, "auth.refresh_secret_key must be at least 32 characters in production")
+		check(c.Auth.Cookie.Secure, "auth.cookie.secure must be enabled in production")
+	}
+	oneOf("auth.cookie.same_site", strings.ToLower(c.Auth.Cookie.SameSite), "", "lax", "strict", "none")
+	check(!strings.EqualFold(c.Auth.Cookie.SameSite, "none") || c.Auth.Cookie.Secure, "auth.cookie.same_site=none requires secure cookies")
+
+	for _, provider := range c.OIDC.Providers {
+		check(provider.Name != "", "oidc.providers: name is required")
+		check(isURL(provider.IssuerURL), "oidc.providers[%s].issuer_url must be an absolute URL", provider.Name)
+		check(provider.ClientID != "", "oidc.providers[%s].client_id is required", provider.Name)
+		check(isURL(provider.RedirectURL), "oidc.providers[%s].redirect_url must be an absolute URL", provider.Name)
+	}
+	check(c.OIDC.SuccessRedirectURL == "" || isURL(c.OIDC.SuccessRedirectURL), "oidc.success_redirect_url must be an absolute URL")
+
+	check(c.Cors.MaxAge >= 0, "cors.max_age must not be negative")
+	check(!c.Cors.AllowCredentials || !slices.Contains(c.Cors.AllowedOrigins, "*"), "cors.allowed_origins must not contain * when cors.allow_credentials is enabled")
+
+	oneOf("rate_limit.store", c.RateLimit.Store, StoreMemory, StorePostgres, StoreRedis)
+	check(c.RateLimit.Store != StorePostgres || c.DB.Driver == DBDriverPostgres, "rate_limit.store=postgres requires db.driver=postgres")
+	for _, policy := range c.RateLimit.Policies {
+		check(policy.Requests > 0 && policy.Period > 0 && policy.Burst > 0, "rate_limit.policies[%s %s]: requests, period and burst must be positive", policy.Method, policy.PathPrefix)
+		oneOf("rate_limit.policies key", policy.Key, RateLimitKeyIP, RateLimitKeyUser, RateLimitKeyAPIKey)
+	}
+
+	check(c.Link.HashLength >= 6 && c.Link.HashLength <= maxHashLength, "link.hash_length must be between 6 and %d, got %d", maxHashLength, c.Link.HashLength)
+	check(c.Link.CodePoolSize >= 0, "link.code_pool_size must not be negative")
+	oneOf("link.code.strategy", c.Link.Code.Strategy, CodeStrategyRandom, CodeStrategyCounter, CodeStrategyPronounceable, CodeStrategySequential)
+	if c.Link.Code.Strategy != CodeStrategyPronounceable {
+		check(validAlphabet(c.Link.Code.Alphabet), "link.code.alphabet must contain at least 10 distinct characters from [0-9A-Za-z_-], got %q", c.Link.Code.Alphabet)
+	}
+	if c.Link.Cache.Enabled {
+		oneOf("link.cache.store", c.Link.Cache.Store, StoreMemory, StoreRedis)
+		check(c.Link.Cache.Size > 0, "link.cache.size must be positive")
+		check(c.Link.Cache.TTL > 0, "link.cache.ttl must be positive")
+		check(c.Link.Cache.NegativeTTL >= 0, "link.cache.negative_ttl must not be negative")
+	}
+
+	oneOf("events.bridge", c.Events.Bridge, EventsBridgeNone, EventsBridgePostgres)
+	check(c.Events.Bridge != EventsBridgePostgres || c.DB.Driver == DBDriverPostgres, "events.bridge=postgres requires db.driver=postgres")
+	check(c.Events.Bridge != EventsBridgePostgres || c.Events.Channel != "", "events.channel is required for events.bridge=postgres")
+
+	oneOf("stats.click_buffer", c.Stats.ClickBuffer, StoreMemory, StoreRedis)
+	check(!c.UsesRedis() || c.Redis.URL != "", "redis.url is required (REDIS_URL) when a store is set to redis")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func isURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.Scheme != "" && parsed.Host != ""
+}
+
+// Символы кода попадают в путь /link/{hash}, поэтому допускаются только безопасные для URL
+func validAlphabet(alphabet string) bool {
+	seen := make(map[rune]bool, len(alphabet))
+	for _, char := range alphabet {
+		safe := char >= '0' && char <= '9' || char >= 'A' && char <= 'Z' || char >= 'a' && char <= 'z' || char == '_' || char == '-'
+		if !safe || seen[char] {
+			return false
+		}
+		seen[char] = true
+	}
+	return len(seen) >= 10
+}
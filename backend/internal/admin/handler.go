@@ -0,0 +1,238 @@
+package admin
+
+import (
+	"errors"
+	"linkshortener/config"
+	"linkshortener/internal/audit"
+	"linkshortener/internal/link"
+	"linkshortener/pkg/di"
+	"linkshortener/pkg/event"
+	"linkshortener/pkg/middleware"
+	"linkshortener/pkg/req"
+	"linkshortener/pkg/res"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+type AdminHandlerDeps struct {
+	Config         *config.Config
+	UserRepository di.IUserRepository
+	LinkRepository di.ILinkRepository[link.Link]
+	CodeGenerator  link.CodeGenerator
+	EventBus       di.IEventBus
+	AuditLogger    di.IAuditLogger
+}
+
+type AdminHandler struct {
+	deps *AdminHandlerDeps
+}
+
+func NewAdminHandler(router *http.ServeMux, deps *AdminHandlerDeps) {
+	adminHandler := &AdminHandler{
+		deps: deps,
+	}
+	router.Handle("GET /admin/users", adminHandler.guard(adminHandler.GetUsers()))
+	router.Handle("POST /admin/users/{id}/disable", adminHandler.guard(adminHandler.SetUserDisabled(true)))
+	router.Handle("POST /admin/users/{id}/enable", adminHandler.guard(adminHandler.SetUserDisabled(false)))
+	router.Handle("PATCH /admin/users/{id}/role", adminHandler.guard(adminHandler.UpdateRole()))
+	router.Handle("GET /admin/links", adminHandler.guard(adminHandler.GetLinks()))
+	router.Handle("GET /admin/links/codes", adminHandler.guard(adminHandler.GetCodes()))
+	router.Handle("POST /admin/links/{id}/disable", adminHandler.guard(adminHandler.SetLinkDisabled(true)))
+	router.Handle("POST /admin/links/{id}/enable", adminHandler.guard(adminHandler.SetLinkDisabled(false)))
+}
+
+func (handler *AdminHandler) guard(next http.Handler) http.Handler {
+	return middleware.IsAdmin(next, handler.deps.Config, handler.deps.UserRepository)
+}
+
+func (handler *AdminHandler) GetUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, err := req.Pagination(r)
+		if err != nil {
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidPagination)
+			return
+		}
+
+		search := r.URL.Query().Get("search")
+		users, err := handler.deps.UserRepository.GetUsers(r.Context(), search, limit, offset)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		count, err := handler.deps.UserRepository.GetUsersCount(r.Context(), search)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		response := GetUsersResponse{
+			Users: make([]UserResponse, 0, len(users)),
+			Count: count,
+		}
+		for i := range users {
+			response.Users = append(response.Users, NewUserResponse(&users[i]))
+		}
+
+		res.Response(w, 200, response)
+	}
+}
+
+func (handler *AdminHandler) SetUserDisabled(disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidID)
+			return
+		}
+
+		if isCurrentUser(r, uint(id)) {
+			res.Error(w, r, http.StatusBadRequest, res.CodeCannotChangeOwnAccount)
+			return
+		}
+
+		updatedUser, err := handler.deps.UserRepository.SetDisabled(r.Context(), uint(id), disabled)
+		if err != nil {
+			notFound(w, r, err, res.CodeUserNotFound)
+			return
+		}
+
+		action := audit.ActionUserEnabled
+		if disabled {
+			action = audit.ActionUserDisabled
+		}
+		handler.deps.AuditLogger.Log(r, action, audit.Target("user", updatedUser.Email),
+			map[string]bool{"disabled": !disabled}, map[string]bool{"disabled": disabled})
+
+		res.Response(w, 200, NewUserResponse(updatedUser))
+	}
+}
+
+func (handler *AdminHandler) UpdateRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[UpdateRoleRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidID)
+			return
+		}
+
+		if isCurrentUser(r, uint(id)) {
+			res.Error(w, r, http.StatusBadRequest, res.CodeCannotChangeOwnAccount)
+			return
+		}
+
+		existingUser, err := handler.deps.UserRepository.FindById(r.Context(), uint(id))
+		if err != nil {
+			notFound(w, r, err, res.CodeUserNotFound)
+			return
+		}
+		previousRole := existingUser.Role
+
+		updatedUser, err := handler.deps.UserRepository.SetRole(r.Context(), uint(id), body.Role)
+		if err != nil {
+			notFound(w, r, err, res.CodeUserNotFound)
+			return
+		}
+
+		handler.deps.AuditLogger.Log(r, audit.ActionUserRoleChanged, audit.Target("user", updatedUser.Email),
+			map[string]string{"role": previousRole}, map[string]string{"role": updatedUser.Role})
+
+		res.Response(w, 200, NewUserResponse(updatedUser))
+	}
+}
+
+func (handler *AdminHandler) GetLinks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, err := req.Pagination(r)
+		if err != nil {
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidPagination)
+			return
+		}
+
+		links, err := handler.deps.LinkRepository.GetLinks(r.Context(), limit, offset)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		count, err := handler.deps.LinkRepository.GetLinksCount(r.Context())
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		res.Response(w, 200, GetLinksResponse{
+			Links: links,
+			Count: count,
+		})
+	}
+}
+
+// Оценка коллизий коротких кодов: удалённые ссылки тоже занимают hash, поэтому считаются все выданные id
+func (handler *AdminHandler) GetCodes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taken, err := handler.deps.CodeGenerator.Taken(r.Context(), handler.deps.LinkRepository)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		res.Response(w, 200, GetCodesResponse{
+			Strategy:             handler.deps.Config.Link.Code.Strategy,
+			Length:               handler.deps.Config.Link.HashLength,
+			Taken:                taken,
+			CollisionProbability: handler.deps.CodeGenerator.CollisionProbability(taken),
+		})
+	}
+}
+
+func (handler *AdminHandler) SetLinkDisabled(disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidID)
+			return
+		}
+
+		updatedLink, err := handler.deps.LinkRepository.SetDisabled(r.Context(), uint(id), disabled)
+		if err != nil {
+			notFound(w, r, err, res.CodeLinkNotFound)
+			return
+		}
+
+		handler.deps.EventBus.Publish(event.NewEvent(r.Context(), event.LinkUpdated, event.LinkChanged{
+			ID:     updatedLink.ID,
+			Hashes: []string{updatedLink.Hash},
+		}))
+
+		action := audit.ActionLinkEnabled
+		if disabled {
+			action = audit.ActionLinkDisabled
+		}
+		handler.deps.AuditLogger.Log(r, action, audit.Target("link", updatedLink.ID),
+			map[string]bool{"disabled": !disabled}, map[string]bool{"disabled": disabled})
+
+		res.Response(w, 200, updatedLink)
+	}
+}
+
+// Отсутствие записи — 404 с кодом сущности, прочие ошибки БД — 500 без подробностей
+func notFound(w http.ResponseWriter, r *http.Request, err error, code string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		res.Error(w, r, http.StatusNotFound, code)
+		return
+	}
+	res.InternalError(w, r, err)
+}
+
+func isCurrentUser(r *http.Request, id uint) bool {
+	currentUser, ok := middleware.CurrentUser(r.Context())
+	return ok && currentUser.ID == id
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"linkshortener/internal/link"
+	"linkshortener/internal/user"
+	"time"
+)
+
+type UserResponse struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetUsersResponse struct {
+	Users []UserResponse `json:"users"`
+	Count int64          `json:"count"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+type GetLinksResponse struct {
+	Links []link.Link `json:"links"`
+	Count int64       `json:"count"`
+}
+
+type GetCodesResponse struct {
+	Strategy string `json:"strategy"`
+	Length   int    `json:"length"`
+	Taken    int64  `json:"taken"`
+	// Вероятность, что новый код совпадёт с занятым и вставку придётся повторить
+	CollisionProbability float64 `json:"collision_probability"`
+}
+
+func NewUserResponse(u *user.User) UserResponse {
+	return UserResponse{
+		ID:        u.ID,
+		Email:     u.Email,
+		Name:      u.Name,
+		Role:      u.Role,
+		Disabled:  u.Disabled,
+		CreatedAt: u.CreatedAt,
+	}
+}
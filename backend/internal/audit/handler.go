@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"linkshortener/config"
+	"linkshortener/pkg/di"
+	"linkshortener/pkg/middleware"
+	"linkshortener/pkg/req"
+	"linkshortener/pkg/res"
+	"net/http"
+	"time"
+)
+
+type AuditHandlerDeps struct {
+	Config          *config.Config
+	AuditRepository di.IAuditRepository[AuditLog, AuditFilter]
+	UserRepository  di.IUserRepository
+}
+
+type AuditHandler struct {
+	deps *AuditHandlerDeps
+}
+
+func NewAuditHandler(router *http.ServeMux, deps *AuditHandlerDeps) {
+	auditHandler := &AuditHandler{
+		deps: deps,
+	}
+	router.Handle("GET /audit", middleware.IsAdmin(auditHandler.GetEntries(), deps.Config, deps.UserRepository))
+}
+
+func (handler *AuditHandler) GetEntries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		limit, offset, err := req.Pagination(r)
+		if err != nil {
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidPagination)
+			return
+		}
+
+		filter := AuditFilter{
+			Action:     query.Get("action"),
+			ActorEmail: query.Get("actor"),
+			Target:     query.Get("target"),
+		}
+
+		if from := query.Get("from"); from != "" {
+			filter.From, err = time.Parse("2006-01-02", from)
+			if err != nil {
+				res.Error(w, r, http.StatusBadRequest, res.CodeInvalidDate)
+				return
+			}
+		}
+
+		if to := query.Get("to"); to != "" {
+			endDate, err := time.Parse("2006-01-02", to)
+			if err != nil {
+				res.Error(w, r, http.StatusBadRequest, res.CodeInvalidDate)
+				return
+			}
+			filter.To = endDate.AddDate(0, 0, 1)
+		}
+
+		entries, err := handler.deps.AuditRepository.GetEntries(r.Context(), filter, limit, offset)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		count, err := handler.deps.AuditRepository.GetEntriesCount(r.Context(), filter)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		res.Response(w, 200, GetAuditResponse{
+			Entries: entries,
+			Count:   count,
+		})
+	}
+}
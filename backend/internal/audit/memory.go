@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Журнал аудита в памяти процесса (драйвер БД memory); записи только добавляются
+type MemoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []AuditLog
+}
+
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+func (repo *MemoryAuditRepository) Create(ctx context.Context, entry *AuditLog) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	entry.ID = uint(len(repo.entries) + 1)
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	repo.entries = append(repo.entries, *entry)
+	return nil
+}
+
+func (repo *MemoryAuditRepository) GetEntries(ctx context.Context, filter AuditFilter, limit, offset uint) ([]AuditLog, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	entries := make([]AuditLog, 0)
+	// Записи добавляются по возрастанию ID, поэтому обратный обход даёт порядок id DESC
+	for i := len(repo.entries) - 1; i >= 0 && uint(len(entries)) < limit; i-- {
+		if !filter.matches(repo.entries[i]) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		entries = append(entries, repo.entries[i])
+	}
+	return entries, nil
+}
+
+func (repo *MemoryAuditRepository) GetEntriesCount(ctx context.Context, filter AuditFilter) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var count int64
+	for _, entry := range repo.entries {
+		if filter.matches(entry) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (filter AuditFilter) matches(entry AuditLog) bool {
+	return (filter.Action == "" || entry.Action == filter.Action) &&
+		(filter.ActorEmail == "" || entry.ActorEmail == filter.ActorEmail) &&
+		(filter.Target == "" || entry.Target == filter.Target) &&
+		(filter.From.IsZero() || !entry.CreatedAt.Before(filter.From)) &&
+		(filter.To.IsZero() || entry.CreatedAt.Before(filter.To))
+}
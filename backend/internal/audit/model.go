@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	ActionLinkCreated     = "link.created"
+	ActionLinkUpdated     = "link.updated"
+	ActionLinkDeleted     = "link.deleted"
+	ActionLinkDisabled    = "link.disabled"
+	ActionLinkEnabled     = "link.enabled"
+	ActionLoginSucceeded  = "auth.login_succeeded"
+	ActionLoginFailed     = "auth.login_failed"
+	ActionLogout          = "auth.logout"
+	ActionRefreshFailed   = "auth.refresh_failed"
+	ActionUserDisabled    = "user.disabled"
+	ActionUserEnabled     = "user.enabled"
+	ActionUserRoleChanged = "user.role_changed"
+)
+
+// Запись журнала аудита. Записи только добавляются, изменение и удаление запрещены триггером в БД
+type AuditLog struct {
+	ID         uint           `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time      `gorm:"not null;index" json:"created_at"`
+	ActorID    *uint          `gorm:"index" json:"actor_id"`
+	ActorEmail string         `json:"actor_email"`
+	Action     string         `gorm:"not null;index" json:"action"`
+	Target     string         `gorm:"not null;index" json:"target"`
+	IP         string         `json:"ip"`
+	UserAgent  string         `json:"user_agent"`
+	Before     datatypes.JSON `json:"before"`
+	After      datatypes.JSON `json:"after"`
+}
+
+func Target(kind string, id any) string {
+	return fmt.Sprintf("%s:%v", kind, id)
+}
@@ -0,0 +1,6 @@
+package audit
+
+type GetAuditResponse struct {
+	Entries []AuditLog `json:"entries"`
+	Count   int64      `json:"count"`
+}
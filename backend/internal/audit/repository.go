@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"context"
+	"linkshortener/pkg/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditFilter struct {
+	Action     string
+	ActorEmail string
+	Target     string
+	From       time.Time
+	To         time.Time
+}
+
+type AuditRepository struct {
+	db *db.Db
+}
+
+func NewAuditRepository(db *db.Db) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (repo *AuditRepository) Create(ctx context.Context, entry *AuditLog) error {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+	return tx.Table("audit_logs").Create(entry).Error
+}
+
+func (repo *AuditRepository) GetEntries(ctx context.Context, filter AuditFilter, limit, offset uint) ([]AuditLog, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var entries []AuditLog
+
+	result := repo.filter(tx, filter).
+		Order("id DESC").
+		Limit(int(limit)).
+		Offset(int(offset)).
+		Find(&entries)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return entries, nil
+}
+
+func (repo *AuditRepository) GetEntriesCount(ctx context.Context, filter AuditFilter) (int64, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var count int64
+	result := repo.filter(tx, filter).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+func (repo *AuditRepository) filter(tx *gorm.DB, filter AuditFilter) *gorm.DB {
+	query := tx.Model(&AuditLog{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorEmail != "" {
+		query = query.Where("actor_email = ?", filter.ActorEmail)
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	return query
+}
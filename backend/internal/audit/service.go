@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"linkshortener/pkg/di"
+	"linkshortener/pkg/middleware"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"gorm.io/datatypes"
+)
+
+type AuditServiceDeps struct {
+	AuditRepository di.IAuditRepository[AuditLog, AuditFilter]
+}
+
+type AuditService struct {
+	deps *AuditServiceDeps
+}
+
+func NewAuditService(deps *AuditServiceDeps) *AuditService {
+	return &AuditService{deps: deps}
+}
+
+func (s *AuditService) Log(r *http.Request, action, target string, before, after any) {
+	entry := &AuditLog{
+		Action:    action,
+		Target:    target,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+		Before:    toJSON(before),
+		After:     toJSON(after),
+	}
+
+	if actor, ok := middleware.CurrentUser(r.Context()); ok {
+		entry.ActorID = &actor.ID
+		entry.ActorEmail = actor.Email
+	}
+
+	// Запись журнала не должна теряться, если клиент отключился сразу после изменения
+	if err := s.deps.AuditRepository.Create(context.WithoutCancel(r.Context()), entry); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write audit log", "action", action, "error", err)
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func toJSON(value any) datatypes.JSON {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return datatypes.JSON(data)
+}
@@ -0,0 +1,132 @@
+package audit_test
+
+import (
+	"linkshortener/config"
+	"linkshortener/internal/audit"
+	"linkshortener/internal/auth"
+	"linkshortener/internal/user"
+	"linkshortener/pkg/jwt"
+	"linkshortener/pkg/session"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogoutIsAudited(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.SecretKey = "secret"
+	cfg.Auth.RefreshTokenSecretKey = "refresh"
+	jwtService := jwt.NewJWT(cfg.Auth.SecretKey, cfg.Auth.RefreshTokenSecretKey)
+
+	users := user.NewMemoryUserRepository()
+	alice, _ := users.Create(t.Context(), user.NewUser("alice@example.com", "hash", "Alice"))
+	accessToken, refreshToken, err := jwtService.CreateTokenPair(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auditRepository := audit.NewMemoryAuditRepository()
+	router := http.NewServeMux()
+	auth.NewAuthHandler(router, &auth.AuthHandlerDeps{
+		Config:      cfg,
+		AuthService: auth.NewAuthService(users, jwtService),
+		AuditLogger: audit.NewAuditService(&audit.AuditServiceDeps{AuditRepository: auditRepository}),
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	r.Header.Set("Authorization", "Bearer "+accessToken)
+	r.Header.Set("X-Refresh-Token", refreshToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	entries, err := auditRepository.GetEntries(t.Context(), audit.AuditFilter{Action: audit.ActionLogout}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 logout entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.ActorID == nil || *entry.ActorID != alice.ID || entry.Target != audit.Target("user", alice.Email) {
+		t.Fatalf("expected logout by %s, got %+v", alice.Email, entry)
+	}
+}
+
+func TestAnonymousLogoutIsNotAudited(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.SecretKey = "secret"
+	cfg.Auth.RefreshTokenSecretKey = "refresh"
+	jwtService := jwt.NewJWT(cfg.Auth.SecretKey, cfg.Auth.RefreshTokenSecretKey)
+
+	auditRepository := audit.NewMemoryAuditRepository()
+	router := http.NewServeMux()
+	auth.NewAuthHandler(router, &auth.AuthHandlerDeps{
+		Config:      cfg,
+		AuthService: auth.NewAuthService(user.NewMemoryUserRepository(), jwtService),
+		AuditLogger: audit.NewAuditService(&audit.AuditServiceDeps{AuditRepository: auditRepository}),
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	r.Header.Set("Authorization", "Bearer garbage")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if entries, _ := auditRepository.GetEntries(t.Context(), audit.AuditFilter{}, 10, 0); len(entries) != 0 {
+		t.Fatalf("expected no entries for anonymous logout, got %+v", entries)
+	}
+}
+
+func TestRefreshFailureIsAuditedOnlyForDisabledUsers(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.SecretKey = "secret"
+	cfg.Auth.RefreshTokenSecretKey = "refresh"
+	cfg.Auth.Cookie.Enabled = true
+	jwtService := jwt.NewJWT(cfg.Auth.SecretKey, cfg.Auth.RefreshTokenSecretKey)
+
+	users := user.NewMemoryUserRepository()
+	alice, _ := users.Create(t.Context(), user.NewUser("alice@example.com", "hash", "Alice"))
+	_, refreshToken, err := jwtService.CreateTokenPair(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auditRepository := audit.NewMemoryAuditRepository()
+	router := http.NewServeMux()
+	auth.NewAuthHandler(router, &auth.AuthHandlerDeps{
+		Config:      cfg,
+		AuthService: auth.NewAuthService(users, jwtService),
+		AuditLogger: audit.NewAuditService(&audit.AuditServiceDeps{AuditRepository: auditRepository}),
+	})
+	refresh := func(token string) {
+		r := httptest.NewRequest(http.MethodPost, "/auth/refresh/cookie", nil)
+		r.AddCookie(&http.Cookie{Name: session.RefreshTokenCookie, Value: token})
+		r.AddCookie(&http.Cookie{Name: session.CSRFCookie, Value: "csrf"})
+		r.Header.Set(session.CSRFHeader, "csrf")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401, got %d", w.Code)
+		}
+	}
+
+	refresh("garbage")
+	if entries, _ := auditRepository.GetEntries(t.Context(), audit.AuditFilter{}, 10, 0); len(entries) != 0 {
+		t.Fatalf("expected invalid token to leave no entries, got %+v", entries)
+	}
+
+	users.SetDisabled(t.Context(), alice.ID, true)
+	refresh(refreshToken)
+	entries, err := auditRepository.GetEntries(t.Context(), audit.AuditFilter{}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != audit.ActionRefreshFailed || entries[0].Target != audit.Target("user", alice.Email) {
+		t.Fatalf("expected one refresh failure for %s, got %+v", alice.Email, entries)
+	}
+}
@@ -1,14 +1,33 @@
 package auth
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
+	"linkshortener/config"
+	"linkshortener/internal/audit"
+	"linkshortener/pkg/di"
+	"linkshortener/pkg/middleware"
 	"linkshortener/pkg/req"
 	"linkshortener/pkg/res"
+	"linkshortener/pkg/session"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStatePath   = "/auth/oidc/"
+	oidcStateTTL    = 10 * time.Minute
 )
 
 type AuthHandlerDeps struct {
+	Config      *config.Config
 	AuthService *AuthService
+	OIDCService *OIDCService
+	AuditLogger di.IAuditLogger
 }
 
 type AuthHandler struct {
@@ -22,6 +41,10 @@ func NewAuthHandler(router *http.ServeMux, deps *AuthHandlerDeps) {
 	router.HandleFunc("POST /auth/register", authHandler.Register())
 	router.HandleFunc("POST /auth/login", authHandler.Login())
 	router.HandleFunc("POST /auth/refresh", authHandler.RefreshToken())
+	router.HandleFunc("POST /auth/refresh/cookie", authHandler.RefreshCookie())
+	router.HandleFunc("POST /auth/logout", authHandler.Logout())
+	router.HandleFunc("GET /auth/oidc/{provider}/login", authHandler.OIDCLogin())
+	router.HandleFunc("GET /auth/oidc/{provider}/callback", authHandler.OIDCCallback())
 }
 
 func (handler *AuthHandler) Register() http.HandlerFunc {
@@ -31,9 +54,13 @@ func (handler *AuthHandler) Register() http.HandlerFunc {
 			return
 		}
 
-		user, err := handler.deps.AuthService.Register(body.Email, body.Password, body.Name)
+		user, err := handler.deps.AuthService.Register(r.Context(), body.Email, body.Password, body.Name)
+		if errors.Is(err, ErrUserExists) {
+			res.Error(w, r, http.StatusConflict, res.CodeUserExists)
+			return
+		}
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			res.InternalError(w, r, err)
 			return
 		}
 
@@ -48,15 +75,33 @@ func (handler *AuthHandler) Login() http.HandlerFunc {
 			return
 		}
 
-		user, err := handler.deps.AuthService.Login(body.Email, body.Password)
+		user, err := handler.deps.AuthService.Login(r.Context(), body.Email, body.Password)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			handler.deps.AuditLogger.Log(r, audit.ActionLoginFailed, audit.Target("user", body.Email), nil, map[string]string{
+				"reason": err.Error(),
+			})
+			switch {
+			case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrInvalidPassword):
+				res.Error(w, r, http.StatusBadRequest, res.CodeInvalidCredentials)
+			case errors.Is(err, ErrUserDisabled):
+				res.Error(w, r, http.StatusForbidden, res.CodeUserDisabled)
+			default:
+				res.InternalError(w, r, err)
+			}
 			return
 		}
+		handler.deps.AuditLogger.Log(r.WithContext(middleware.WithUser(r.Context(), user)),
+			audit.ActionLoginSucceeded, audit.Target("user", user.Email), nil, nil)
 
 		accessToken, refreshToken, err := handler.deps.AuthService.jwt.CreateTokenPair(user)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			res.InternalError(w, r, err)
+			return
+		}
+
+		if handler.cookieMode() {
+			csrfToken := session.SetTokens(w, &handler.deps.Config.Auth.Cookie, accessToken, refreshToken)
+			res.Response(w, 200, CookieLoginResponse{CSRFToken: csrfToken})
 			return
 		}
 
@@ -74,21 +119,202 @@ func (handler *AuthHandler) RefreshToken() http.HandlerFunc {
 			return
 		}
 
-		user, err := handler.deps.AuthService.jwt.ValidateRefreshToken(body.RefreshToken)
+		accessToken, newRefreshToken, err := handler.deps.AuthService.Refresh(r.Context(), body.RefreshToken)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+			handler.refreshError(w, r, err)
 			return
 		}
 
-		accessToken, newRefreshToken, err := handler.deps.AuthService.jwt.CreateTokenPair(user)
+		res.Response(w, 200, RefreshTokenResponse{
+			AccessToken:  accessToken,
+			RefreshToken: newRefreshToken,
+		})
+	}
+}
+
+func (handler *AuthHandler) RefreshCookie() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !handler.cookieMode() {
+			res.Error(w, r, http.StatusNotFound, res.CodeCookieModeDisabled)
+			return
+		}
+
+		cookie, err := r.Cookie(session.RefreshTokenCookie)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			res.Error(w, r, http.StatusUnauthorized, res.CodeUnauthorized)
 			return
 		}
 
-		res.Response(w, 200, RefreshTokenResponse{
+		if !session.ValidCSRF(r) {
+			res.Error(w, r, http.StatusForbidden, res.CodeInvalidCSRFToken)
+			return
+		}
+
+		accessToken, refreshToken, err := handler.deps.AuthService.Refresh(r.Context(), cookie.Value)
+		if err != nil {
+			session.Clear(w, &handler.deps.Config.Auth.Cookie)
+			// Токены не отзываются на сервере, поэтому в журнал попадает только отказ по действующему токену
+			// заблокированного пользователя; просроченные и поддельные cookie — обычный шум
+			if errors.Is(err, ErrUserDisabled) {
+				handler.logSessionEnd(r, audit.ActionRefreshFailed, "", cookie.Value)
+			}
+			handler.refreshError(w, r, err)
+			return
+		}
+
+		csrfToken := session.SetTokens(w, &handler.deps.Config.Auth.Cookie, accessToken, refreshToken)
+		res.Response(w, 200, CookieLoginResponse{CSRFToken: csrfToken})
+	}
+}
+
+func (handler *AuthHandler) Logout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if handler.cookieMode() && !session.ValidCSRF(r) {
+			res.Error(w, r, http.StatusForbidden, res.CodeInvalidCSRFToken)
+			return
+		}
+
+		accessToken, refreshToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), r.Header.Get("X-Refresh-Token")
+		if handler.cookieMode() {
+			accessToken, refreshToken = cookieValue(r, session.AccessTokenCookie), cookieValue(r, session.RefreshTokenCookie)
+		}
+
+		session.Clear(w, &handler.deps.Config.Auth.Cookie)
+		handler.logSessionEnd(r, audit.ActionLogout, accessToken, refreshToken)
+		res.Response(w, 200, nil)
+	}
+}
+
+// Записывает в журнал завершение сессии от имени владельца токенов. Без действующего токена
+// запись не делается: иначе анонимные запросы на выход засоряли бы журнал
+func (handler *AuthHandler) logSessionEnd(r *http.Request, action, accessToken, refreshToken string) {
+	subject, err := handler.deps.AuthService.TokenSubject(r.Context(), accessToken, refreshToken)
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to resolve session owner", "error", err)
+	}
+	if subject == nil {
+		return
+	}
+	handler.deps.AuditLogger.Log(r.WithContext(middleware.WithUser(r.Context(), subject)),
+		action, audit.Target("user", subject.Email), nil, nil)
+}
+
+func cookieValue(r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func (handler *AuthHandler) refreshError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidRefreshToken):
+		res.Error(w, r, http.StatusUnauthorized, res.CodeInvalidRefreshToken)
+	case errors.Is(err, ErrUserDisabled):
+		res.Error(w, r, http.StatusUnauthorized, res.CodeUserDisabled)
+	default:
+		res.InternalError(w, r, err)
+	}
+}
+
+func (handler *AuthHandler) cookieMode() bool {
+	return handler.deps.Config != nil && handler.deps.Config.Auth.Cookie.Enabled
+}
+
+func (handler *AuthHandler) OIDCLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request, err := handler.deps.OIDCService.AuthCodeURL(r.Context(), r.PathValue("provider"))
+		if errors.Is(err, ErrUnknownProvider) {
+			res.Error(w, r, http.StatusNotFound, res.CodeOIDCProviderNotFound)
+			return
+		}
+		if err != nil {
+			slog.WarnContext(r.Context(), "oidc discovery failed", "provider", r.PathValue("provider"), "error", err)
+			res.Error(w, r, http.StatusBadGateway, res.CodeOIDCProviderUnavailable)
+			return
+		}
+
+		// Secure берётся из настроек: за nginx, завершающим TLS, r.TLS всегда nil
+		session.SetFlowCookie(w, &handler.deps.Config.Auth.Cookie, oidcStateCookie,
+			strings.Join([]string{request.State, request.Nonce, request.Verifier}, "."), oidcStatePath, oidcStateTTL)
+		http.Redirect(w, r, request.URL, http.StatusFound)
+	}
+}
+
+func (handler *AuthHandler) OIDCCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := r.PathValue("provider")
+
+		cookie, err := r.Cookie(oidcStateCookie)
+		if err != nil {
+			res.Error(w, r, http.StatusBadRequest, res.CodeOIDCStateMissing)
+			return
+		}
+		session.ClearFlowCookie(w, &handler.deps.Config.Auth.Cookie, oidcStateCookie, oidcStatePath)
+
+		parts := strings.Split(cookie.Value, ".")
+		if len(parts) != 3 || parts[0] != r.URL.Query().Get("state") {
+			res.Error(w, r, http.StatusBadRequest, res.CodeOIDCStateInvalid)
+			return
+		}
+
+		if errorCode := r.URL.Query().Get("error"); errorCode != "" {
+			slog.InfoContext(r.Context(), "oidc provider returned error", "provider", provider, "error", errorCode)
+			res.Error(w, r, http.StatusUnauthorized, res.CodeOIDCLoginFailed)
+			return
+		}
+
+		user, err := handler.deps.OIDCService.Exchange(r.Context(), provider, r.URL.Query().Get("code"), parts[2], parts[1])
+		if errors.Is(err, ErrUnknownProvider) {
+			res.Error(w, r, http.StatusNotFound, res.CodeOIDCProviderNotFound)
+			return
+		}
+		if err != nil {
+			handler.deps.AuditLogger.Log(r, audit.ActionLoginFailed, audit.Target("oidc", provider), nil, map[string]string{
+				"reason": err.Error(),
+			})
+			if errors.Is(err, ErrUserDisabled) {
+				res.Error(w, r, http.StatusForbidden, res.CodeUserDisabled)
+				return
+			}
+			res.Error(w, r, http.StatusUnauthorized, res.CodeOIDCLoginFailed)
+			return
+		}
+		handler.deps.AuditLogger.Log(r.WithContext(middleware.WithUser(r.Context(), user)),
+			audit.ActionLoginSucceeded, audit.Target("user", user.Email), nil, map[string]string{
+				"provider": provider,
+			})
+
+		accessToken, refreshToken, err := handler.deps.OIDCService.CreateTokenPair(user)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		redirectURL := handler.deps.Config.OIDC.SuccessRedirectURL
+		if handler.cookieMode() {
+			csrfToken := session.SetTokens(w, &handler.deps.Config.Auth.Cookie, accessToken, refreshToken)
+			if redirectURL != "" {
+				http.Redirect(w, r, redirectURL, http.StatusFound)
+				return
+			}
+			res.Response(w, 200, CookieLoginResponse{CSRFToken: csrfToken})
+			return
+		}
+
+		if redirectURL != "" {
+			fragment := url.Values{
+				"access_token":  {accessToken},
+				"refresh_token": {refreshToken},
+			}
+			http.Redirect(w, r, redirectURL+"#"+fragment.Encode(), http.StatusFound)
+			return
+		}
+
+		res.Response(w, 200, LoginResponse{
 			AccessToken:  accessToken,
-			RefreshToken: newRefreshToken,
+			RefreshToken: refreshToken,
 		})
 	}
 }
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"linkshortener/config"
+	"linkshortener/internal/user"
+	"linkshortener/pkg/di"
+	"linkshortener/pkg/jwt"
+	"linkshortener/pkg/tracing"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+)
+
+var ErrUnknownProvider = errors.New("unknown oidc provider")
+
+type OIDCAuthRequest struct {
+	URL      string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+}
+
+// Discovery выполняется при первом обращении, чтобы недоступный провайдер не блокировал запуск
+type oidcProvider struct {
+	config   config.OIDCProviderConfig
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func (p *oidcProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.config.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	p.provider = provider
+	return provider, nil
+}
+
+func (p *oidcProvider) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	scopes := p.config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+type OIDCService struct {
+	userRepository di.IUserRepository
+	jwt            *jwt.JWT
+	providers      map[string]*oidcProvider
+	httpClient     *http.Client
+}
+
+func NewOIDCService(userRepository di.IUserRepository, jwt *jwt.JWT, providers []config.OIDCProviderConfig) *OIDCService {
+	service := &OIDCService{
+		userRepository: userRepository,
+		jwt:            jwt,
+		providers:      make(map[string]*oidcProvider, len(providers)),
+		httpClient:     tracing.NewClient(),
+	}
+	for _, provider := range providers {
+		service.providers[provider.Name] = &oidcProvider{config: provider}
+	}
+	return service
+}
+
+func (service *OIDCService) AuthCodeURL(ctx context.Context, name string) (*OIDCAuthRequest, error) {
+	p, ok := service.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	ctx = oidc.ClientContext(ctx, service.httpClient)
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &OIDCAuthRequest{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: oauth2.GenerateVerifier(),
+	}
+	request.URL = p.oauth2Config(provider).AuthCodeURL(request.State,
+		oidc.Nonce(request.Nonce),
+		oauth2.S256ChallengeOption(request.Verifier),
+	)
+
+	return request, nil
+}
+
+func (service *OIDCService) Exchange(ctx context.Context, name, code, verifier, nonce string) (*user.User, error) {
+	p, ok := service.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	ctx = oidc.ClientContext(ctx, service.httpClient)
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("id_token is missing")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid nonce")
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errors.New("email is not verified")
+	}
+
+	return service.findOrCreateUser(ctx, claims)
+}
+
+func (service *OIDCService) CreateTokenPair(u *user.User) (string, string, error) {
+	return service.jwt.CreateTokenPair(u)
+}
+
+func (service *OIDCService) findOrCreateUser(ctx context.Context, claims oidcClaims) (*user.User, error) {
+	existingUser, err := service.userRepository.FindByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingUser != nil {
+		if existingUser.Disabled {
+			return nil, ErrUserDisabled
+		}
+		return existingUser, nil
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = strings.Split(claims.Email, "@")[0]
+	}
+
+	// Пароль случайный: вход по паролю для таких пользователей невозможен
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomString()), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.userRepository.Create(ctx, user.NewUser(claims.Email, string(hashedPassword), name))
+}
+
+func randomString() string {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		panic("failed to generate random string: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,260 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"linkshortener/config"
+	"linkshortener/internal/auth"
+	"linkshortener/pkg/jwt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+)
+
+type MockAuditLogger struct{}
+
+func (m *MockAuditLogger) Log(r *http.Request, action, target string, before, after any) {}
+
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+}
+
+type MockOIDCServer struct {
+	*httptest.Server
+	key            *rsa.PrivateKey
+	emailVerified  bool
+	mu             sync.Mutex
+	authorizations map[string]mockAuthorization
+}
+
+func NewMockOIDCServer(t *testing.T) *MockOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := &MockOIDCServer{
+		key:            key,
+		emailVerified:  true,
+		authorizations: make(map[string]mockAuthorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                mock.URL,
+			"authorization_endpoint":                mock.URL + "/authorize",
+			"token_endpoint":                        mock.URL + "/token",
+			"jwks_uri":                              mock.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		mock.mu.Lock()
+		authorization, ok := mock.authorizations[r.Form.Get("code")]
+		mock.mu.Unlock()
+
+		verifierHash := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != authorization.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		idToken := jwtlib.NewWithClaims(jwtlib.SigningMethodRS256, jwtlib.MapClaims{
+			"iss":            mock.URL,
+			"aud":            "test-client",
+			"sub":            "user-1",
+			"email":          "oidc@example.com",
+			"email_verified": mock.emailVerified,
+			"name":           "OIDC User",
+			"nonce":          authorization.nonce,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"iat":            time.Now().Unix(),
+		})
+		idToken.Header["kid"] = "test"
+		signed, err := idToken.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "provider-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     signed,
+		})
+	})
+	mock.Server = httptest.NewServer(mux)
+	t.Cleanup(mock.Close)
+
+	return mock
+}
+
+// Имитация страницы входа провайдера: выдаёт код для параметров из authorization URL
+func (mock *MockOIDCServer) Authorize(authURL string) string {
+	parsed, _ := url.Parse(authURL)
+	query := parsed.Query()
+
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	code := "code-" + query.Get("state")
+	mock.authorizations[code] = mockAuthorization{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+	}
+	return code
+}
+
+func setupOIDC(t *testing.T) (*MockOIDCServer, *httptest.Server, *MockUserRepository) {
+	godotenv.Load()
+	mock := NewMockOIDCServer(t)
+	mockRepo := NewMockUserRepository()
+	jwtService := jwt.NewJWT(os.Getenv("SECRET_KEY"), os.Getenv("REFRESH_SECRET_KEY"))
+
+	router := http.NewServeMux()
+	auth.NewAuthHandler(router, &auth.AuthHandlerDeps{
+		Config:      &config.Config{},
+		AuthService: auth.NewAuthService(mockRepo, jwtService),
+		OIDCService: auth.NewOIDCService(mockRepo, jwtService, []config.OIDCProviderConfig{{
+			Name:        "mock",
+			IssuerURL:   mock.URL,
+			ClientID:    "test-client",
+			RedirectURL: "http://localhost/auth/oidc/mock/callback",
+		}}),
+		AuditLogger: &MockAuditLogger{},
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return mock, server, mockRepo
+}
+
+func oidcLogin(t *testing.T, server *httptest.Server) (string, *http.Cookie) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	res, err := client.Get(server.URL + "/auth/oidc/mock/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("expected status Found, got %d", res.StatusCode)
+	}
+
+	cookies := res.Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected state cookie, got %d cookies", len(cookies))
+	}
+	return res.Header.Get("Location"), cookies[0]
+}
+
+func oidcCallback(t *testing.T, server *httptest.Server, code, state string, cookie *http.Cookie) *http.Response {
+	callbackURL := server.URL + "/auth/oidc/mock/callback?" + url.Values{
+		"code":  {code},
+		"state": {state},
+	}.Encode()
+
+	request, _ := http.NewRequest(http.MethodGet, callbackURL, nil)
+	request.AddCookie(cookie)
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestOIDCLoginSuccess(t *testing.T) {
+	mock, server, mockRepo := setupOIDC(t)
+
+	authURL, cookie := oidcLogin(t, server)
+	parsed, _ := url.Parse(authURL)
+	if parsed.Query().Get("code_challenge_method") != "S256" {
+		t.Fatalf("expected PKCE S256 challenge, got %s", authURL)
+	}
+
+	code := mock.Authorize(authURL)
+	res := oidcCallback(t, server, code, parsed.Query().Get("state"), cookie)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK, got %d", res.StatusCode)
+	}
+
+	response := auth.LoginResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	if response.AccessToken == "" || response.RefreshToken == "" {
+		t.Fatal("expected token pair")
+	}
+
+	if mockRepo.users["oidc@example.com"] == nil {
+		t.Fatal("expected user to be created")
+	}
+}
+
+func TestOIDCLoginInvalidState(t *testing.T) {
+	mock, server, _ := setupOIDC(t)
+
+	authURL, cookie := oidcLogin(t, server)
+	code := mock.Authorize(authURL)
+
+	res := oidcCallback(t, server, code, "forged-state", cookie)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status BadRequest, got %d", res.StatusCode)
+	}
+}
+
+func TestOIDCLoginUnverifiedEmail(t *testing.T) {
+	mock, server, mockRepo := setupOIDC(t)
+	mock.emailVerified = false
+
+	authURL, cookie := oidcLogin(t, server)
+	parsed, _ := url.Parse(authURL)
+	code := mock.Authorize(authURL)
+
+	res := oidcCallback(t, server, code, parsed.Query().Get("state"), cookie)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status Unauthorized, got %d", res.StatusCode)
+	}
+
+	if len(mockRepo.users) != 0 {
+		t.Fatal("expected no user to be created")
+	}
+}
@@ -28,3 +28,7 @@ type RefreshTokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+type CookieLoginResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"linkshortener/internal/user"
 	"linkshortener/pkg/di"
@@ -9,6 +10,14 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrUserExists          = errors.New("user already exists")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidPassword     = errors.New("invalid password")
+	ErrUserDisabled        = errors.New("user is disabled")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+)
+
 type AuthService struct {
 	userRepository di.IUserRepository
 	jwt            *jwt.JWT
@@ -18,14 +27,14 @@ func NewAuthService(userRepository di.IUserRepository, jwt *jwt.JWT) *AuthServic
 	return &AuthService{userRepository: userRepository, jwt: jwt}
 }
 
-func (service *AuthService) Register(email, password, name string) (*user.User, error) {
-	existingUser, err := service.userRepository.FindByEmail(email)
+func (service *AuthService) Register(ctx context.Context, email, password, name string) (*user.User, error) {
+	existingUser, err := service.userRepository.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 
 	if existingUser != nil {
-		return nil, errors.New("user already exists")
+		return nil, ErrUserExists
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -35,7 +44,7 @@ func (service *AuthService) Register(email, password, name string) (*user.User,
 
 	newUser := user.NewUser(email, string(hashedPassword), name)
 
-	_, err = service.userRepository.Create(newUser)
+	_, err = service.userRepository.Create(ctx, newUser)
 	if err != nil {
 		return nil, err
 	}
@@ -43,20 +52,55 @@ func (service *AuthService) Register(email, password, name string) (*user.User,
 	return newUser, nil
 }
 
-func (service *AuthService) Login(email, password string) (*user.User, error) {
-	userExists, err := service.userRepository.FindByEmail(email)
+func (service *AuthService) Login(ctx context.Context, email, password string) (*user.User, error) {
+	userExists, err := service.userRepository.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 
 	if userExists == nil {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(userExists.Password), []byte(password))
 	if err != nil {
-		return nil, errors.New("invalid password")
+		return nil, ErrInvalidPassword
+	}
+
+	if userExists.Disabled {
+		return nil, ErrUserDisabled
 	}
 
 	return userExists, nil
 }
+
+func (service *AuthService) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	tokenUser, err := service.jwt.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	existingUser, err := service.userRepository.FindByEmail(ctx, tokenUser.Email)
+	if err != nil {
+		return "", "", err
+	}
+
+	if existingUser == nil || existingUser.Disabled {
+		return "", "", ErrUserDisabled
+	}
+
+	return service.jwt.CreateTokenPair(existingUser)
+}
+
+// Владелец токенов завершаемой сессии: по refresh-токену, иначе по access-токену.
+// Отключённый пользователь тоже возвращается; nil — ни один токен не действителен
+func (service *AuthService) TokenSubject(ctx context.Context, accessToken, refreshToken string) (*user.User, error) {
+	tokenUser, err := service.jwt.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		tokenUser, err = service.jwt.ValidateToken(accessToken)
+	}
+	if err != nil {
+		return nil, nil
+	}
+	return service.userRepository.FindByEmail(ctx, tokenUser.Email)
+}
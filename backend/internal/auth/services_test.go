@@ -1,9 +1,11 @@
 package auth_test
 
 import (
+	"context"
 	"errors"
 	"linkshortener/internal/auth"
 	"linkshortener/internal/user"
+	"linkshortener/pkg/di"
 	"linkshortener/pkg/jwt"
 	"os"
 	"testing"
@@ -12,7 +14,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Методы, которые AuthService и OIDCService не вызывают, остаются нереализованными
 type MockUserRepository struct {
+	di.IUserRepository
 	users map[string]*user.User
 }
 
@@ -22,14 +26,14 @@ func NewMockUserRepository() *MockUserRepository {
 	}
 }
 
-func (m *MockUserRepository) FindByEmail(email string) (*user.User, error) {
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
 	if user, exists := m.users[email]; exists {
 		return user, nil
 	}
 	return nil, nil
 }
 
-func (m *MockUserRepository) Create(u *user.User) (*user.User, error) {
+func (m *MockUserRepository) Create(ctx context.Context, u *user.User) (*user.User, error) {
 	if _, exists := m.users[u.Email]; exists {
 		return nil, errors.New("user already exists")
 	}
@@ -48,7 +52,7 @@ func TestAuthServiceRegisterSuccess(t *testing.T) {
 	godotenv.Load()
 	authService, _ := setupAuthService()
 
-	user, err := authService.Register("test@example.com", "password123", "Test User")
+	user, err := authService.Register(t.Context(), "test@example.com", "password123", "Test User")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -82,7 +86,7 @@ func TestAuthServiceRegisterUserAlreadyExists(t *testing.T) {
 	}
 	mockRepo.users["test@example.com"] = existingUser
 
-	_, err := authService.Register("test@example.com", "password123", "Test User")
+	_, err := authService.Register(t.Context(), "test@example.com", "password123", "Test User")
 
 	if err == nil {
 		t.Fatal("Expected error for existing user")
@@ -105,7 +109,7 @@ func TestAuthServiceLoginSuccess(t *testing.T) {
 	}
 	mockRepo.users["test@example.com"] = existingUser
 
-	user, err := authService.Login("test@example.com", "password123")
+	user, err := authService.Login(t.Context(), "test@example.com", "password123")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -124,7 +128,7 @@ func TestAuthServiceLoginUserNotFound(t *testing.T) {
 	godotenv.Load()
 	authService, _ := setupAuthService()
 
-	_, err := authService.Login("nonexistent@example.com", "password123")
+	_, err := authService.Login(t.Context(), "nonexistent@example.com", "password123")
 
 	if err == nil {
 		t.Fatal("Expected error for non-existent user")
@@ -147,7 +151,7 @@ func TestAuthServiceLoginInvalidPassword(t *testing.T) {
 	}
 	mockRepo.users["test@example.com"] = existingUser
 
-	_, err := authService.Login("test@example.com", "wrong_password")
+	_, err := authService.Login(t.Context(), "test@example.com", "wrong_password")
 
 	if err == nil {
 		t.Fatal("Expected error for wrong password")
@@ -157,3 +161,53 @@ func TestAuthServiceLoginInvalidPassword(t *testing.T) {
 		t.Fatalf("Expected 'invalid password' error, got %v", err)
 	}
 }
+
+func TestAuthServiceLoginDisabledUser(t *testing.T) {
+	godotenv.Load()
+	authService, mockRepo := setupAuthService()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	mockRepo.users["test@example.com"] = &user.User{
+		Email:    "test@example.com",
+		Password: string(hashedPassword),
+		Name:     "Test User",
+		Disabled: true,
+	}
+
+	_, err := authService.Login(t.Context(), "test@example.com", "password123")
+
+	if err == nil {
+		t.Fatal("Expected error for disabled user")
+	}
+
+	if err.Error() != "user is disabled" {
+		t.Fatalf("Expected 'user is disabled' error, got %v", err)
+	}
+}
+
+func TestAuthServiceRefreshDisabledUser(t *testing.T) {
+	godotenv.Load()
+	authService, mockRepo := setupAuthService()
+
+	existingUser := &user.User{
+		Email: "test@example.com",
+		Name:  "Test User",
+	}
+	mockRepo.users["test@example.com"] = existingUser
+
+	jwtService := jwt.NewJWT(os.Getenv("SECRET_KEY"), os.Getenv("REFRESH_SECRET_KEY"))
+	_, refreshToken, err := jwtService.CreateTokenPair(existingUser)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, _, err := authService.Refresh(t.Context(), refreshToken); err != nil {
+		t.Fatalf("Expected no error for active user, got %v", err)
+	}
+
+	existingUser.Disabled = true
+
+	if _, _, err := authService.Refresh(t.Context(), refreshToken); err == nil {
+		t.Fatal("Expected error for disabled user")
+	}
+}
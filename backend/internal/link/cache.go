@@ -0,0 +1,140 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"linkshortener/config"
+	"linkshortener/pkg/db"
+	"linkshortener/pkg/di"
+	"linkshortener/pkg/event"
+	"linkshortener/pkg/metrics"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	cacheName = "redirect"
+	// Ограничение на сброс кэша из обработчиков событий, у которых нет своего контекста
+	invalidateTimeout = 5 * time.Second
+)
+
+// Кэш перед GetByHash для редиректов. Остальные методы идут в репозиторий напрямую;
+// записи сбрасываются по событиям LinkCreated, LinkUpdated и LinkDeleted, а в худшем случае устаревают через TTL.
+// Ошибки самого кэша не ломают редиректы: запрос просто идёт в репозиторий
+type CachedLinkRepository struct {
+	di.ILinkRepository[Link]
+
+	config config.LinkCacheConfig
+	links  di.ILinkCache[Link]
+	group  singleflight.Group
+	// Увеличивается при каждом сбросе, чтобы загрузка, начатая до изменения ссылки, не положила в кэш старые данные
+	generation atomic.Uint64
+}
+
+func NewCachedLinkRepository(repo di.ILinkRepository[Link], links di.ILinkCache[Link], config config.LinkCacheConfig) *CachedLinkRepository {
+	return &CachedLinkRepository{
+		ILinkRepository: repo,
+		config:          config,
+		links:           links,
+	}
+}
+
+// Отсутствующий hash хранится как nil и отдаётся как gorm.ErrRecordNotFound.
+// Одновременные промахи по одному hash объединяются в один запрос к БД
+func (repo *CachedLinkRepository) GetByHash(ctx context.Context, hash string) (*Link, error) {
+	cached, ok, err := repo.links.Get(ctx, hash)
+	if err != nil {
+		slog.WarnContext(ctx, "redirect cache lookup failed", "hash", hash, "error", err)
+	}
+	if ok {
+		metrics.CacheRequests.WithLabelValues(cacheName, "hit").Inc()
+		if cached == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		copied := *cached
+		return &copied, nil
+	}
+	metrics.CacheRequests.WithLabelValues(cacheName, "miss").Inc()
+
+	// Запрос общий для всех ожидающих, поэтому не прерывается отменой первого из них;
+	// отменённый вызывающий просто перестаёт ждать. Читаем с основной БД: промах часто следует
+	// сразу за сбросом, и отстающая реплика положила бы в кэш старую ссылку на весь TTL
+	loadCtx := db.WithPrimary(context.WithoutCancel(ctx))
+	loaded := repo.group.DoChan(hash, func() (any, error) {
+		generation := repo.generation.Load()
+		link, err := repo.ILinkRepository.GetByHash(loadCtx, hash)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if repo.config.NegativeTTL > 0 {
+				repo.store(loadCtx, hash, nil, repo.config.NegativeTTL, generation)
+			}
+		case err == nil:
+			repo.store(loadCtx, hash, link, repo.config.TTL, generation)
+		}
+		return link, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-loaded:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		copied := *result.Val.(*Link)
+		return &copied, nil
+	}
+}
+
+// Если за время загрузки был сброс, запись удаляется сразу после сохранения:
+// сброс мог пройти между проверкой поколения и Set
+func (repo *CachedLinkRepository) store(ctx context.Context, hash string, link *Link, ttl time.Duration, generation uint64) {
+	if err := repo.links.Set(ctx, hash, link, ttl); err != nil {
+		slog.WarnContext(ctx, "failed to cache link", "hash", hash, "error", err)
+		return
+	}
+	if repo.generation.Load() != generation {
+		if err := repo.links.Delete(ctx, hash); err != nil {
+			slog.WarnContext(ctx, "failed to drop stale cached link", "hash", hash, "error", err)
+		}
+	}
+}
+
+func (repo *CachedLinkRepository) Invalidate(ctx context.Context, hashes ...string) error {
+	repo.generation.Add(1)
+	for _, hash := range hashes {
+		repo.group.Forget(hash)
+	}
+	return repo.links.Delete(ctx, hashes...)
+}
+
+// Сбрасывает весь кэш, когда события об изменениях могли быть потеряны
+func (repo *CachedLinkRepository) InvalidateAll() {
+	repo.generation.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), invalidateTimeout)
+	defer cancel()
+	if err := repo.links.Clear(ctx); err != nil {
+		slog.Error("failed to clear redirect cache", "error", err)
+	}
+}
+
+// Обработчик событий LinkCreated, LinkUpdated и LinkDeleted для EventBus.On. Новая ссылка тоже
+// сбрасывает запись: по её hash мог быть запомнен промах до создания
+func (repo *CachedLinkRepository) HandleEvent(evt event.Event) {
+	changed, ok := evt.Data.(event.LinkChanged)
+	if !ok {
+		slog.Error("bad link change data", "type", evt.Type, "data", evt.Data)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(evt.Context(context.Background()), invalidateTimeout)
+	defer cancel()
+	if err := repo.Invalidate(ctx, changed.Hashes...); err != nil {
+		slog.ErrorContext(ctx, "failed to invalidate cached links", "hashes", changed.Hashes, "error", err)
+	}
+}
@@ -0,0 +1,38 @@
+package link
+
+import (
+	"context"
+	"linkshortener/pkg/cache"
+	"time"
+)
+
+// Кэш ссылок в памяти процесса: LRU ограниченного размера, у каждого инстанса свой
+type MemoryLinkCache struct {
+	links *cache.LRU[string, *Link]
+}
+
+func NewMemoryLinkCache(size int) *MemoryLinkCache {
+	return &MemoryLinkCache{links: cache.NewLRU[string, *Link](size)}
+}
+
+func (c *MemoryLinkCache) Get(ctx context.Context, hash string) (*Link, bool, error) {
+	link, ok := c.links.Get(hash)
+	return link, ok, nil
+}
+
+func (c *MemoryLinkCache) Set(ctx context.Context, hash string, link *Link, ttl time.Duration) error {
+	c.links.Set(hash, link, ttl)
+	return nil
+}
+
+func (c *MemoryLinkCache) Delete(ctx context.Context, hashes ...string) error {
+	for _, hash := range hashes {
+		c.links.Delete(hash)
+	}
+	return nil
+}
+
+func (c *MemoryLinkCache) Clear(ctx context.Context) error {
+	c.links.Clear()
+	return nil
+}
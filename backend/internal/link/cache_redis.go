@@ -0,0 +1,84 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisCachePrefix = "linkshortener:link:"
+
+// Кэш ссылок в Redis, общий для всех инстансов. Отсутствующая ссылка хранится пустой строкой
+type RedisLinkCache struct {
+	client redis.UniversalClient
+}
+
+func NewRedisLinkCache(client redis.UniversalClient) *RedisLinkCache {
+	return &RedisLinkCache{client: client}
+}
+
+func (c *RedisLinkCache) Get(ctx context.Context, hash string) (*Link, bool, error) {
+	data, err := c.client.Get(ctx, redisCachePrefix+hash).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) == 0 {
+		return nil, true, nil
+	}
+
+	var link Link
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil, false, err
+	}
+	return &link, true, nil
+}
+
+func (c *RedisLinkCache) Set(ctx context.Context, hash string, link *Link, ttl time.Duration) error {
+	var data []byte
+	if link != nil {
+		var err error
+		if data, err = json.Marshal(link); err != nil {
+			return err
+		}
+	}
+	return c.client.Set(ctx, redisCachePrefix+hash, data, ttl).Err()
+}
+
+func (c *RedisLinkCache) Delete(ctx context.Context, hashes ...string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	keys := make([]string, len(hashes))
+	for i, hash := range hashes {
+		keys[i] = redisCachePrefix + hash
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// Удаляет все ссылки из кэша через SCAN, не блокируя Redis на время обхода
+func (c *RedisLinkCache) Clear(ctx context.Context) error {
+	iter := c.client.Scan(ctx, 0, redisCachePrefix+"*", 500).Iterator()
+	keys := make([]string, 0, 500)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == cap(keys) {
+			if err := c.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return c.client.Del(ctx, keys...).Err()
+	}
+	return nil
+}
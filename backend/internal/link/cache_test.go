@@ -0,0 +1,230 @@
+package link_test
+
+import (
+	"context"
+	"errors"
+	"linkshortener/config"
+	"linkshortener/internal/link"
+	"linkshortener/pkg/di"
+	"linkshortener/pkg/event"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Считает обращения к GetByHash и может задержать их до закрытия release
+type countingRepository struct {
+	di.ILinkRepository[link.Link]
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingRepository) GetByHash(ctx context.Context, hash string) (*link.Link, error) {
+	r.calls.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return r.ILinkRepository.GetByHash(ctx, hash)
+}
+
+var cacheConfig = config.LinkCacheConfig{
+	Enabled:     true,
+	Size:        100,
+	TTL:         time.Minute,
+	NegativeTTL: time.Minute,
+}
+
+type cacheFactory func(t *testing.T) di.ILinkCache[link.Link]
+
+// Поведение кэша одинаково для хранилища в памяти и в Redis (miniredis в процессе теста)
+var cacheStores = map[string]cacheFactory{
+	"memory": func(t *testing.T) di.ILinkCache[link.Link] {
+		return link.NewMemoryLinkCache(cacheConfig.Size)
+	},
+	"redis": func(t *testing.T) di.ILinkCache[link.Link] {
+		client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		t.Cleanup(func() { client.Close() })
+		return link.NewRedisLinkCache(client)
+	},
+}
+
+func forEachCache(t *testing.T, test func(t *testing.T, newCache cacheFactory)) {
+	for name, newCache := range cacheStores {
+		t.Run(name, func(t *testing.T) {
+			test(t, newCache)
+		})
+	}
+}
+
+func newCachedRepository(t *testing.T, newCache cacheFactory, urls ...string) (*link.CachedLinkRepository, *countingRepository, []*link.Link) {
+	t.Helper()
+	repo, links := newRepository(t, urls...)
+	counting := &countingRepository{ILinkRepository: repo}
+	return link.NewCachedLinkRepository(counting, newCache(t), cacheConfig), counting, links
+}
+
+func TestCacheServesRepeatedLookups(t *testing.T) {
+	forEachCache(t, func(t *testing.T, newCache cacheFactory) {
+		cached, counting, links := newCachedRepository(t, newCache, "https://example.com")
+
+		for i := 0; i < 3; i++ {
+			found, err := cached.GetByHash(t.Context(), links[0].Hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found.OriginalURL != "https://example.com" {
+				t.Fatalf("unexpected link: %+v", found)
+			}
+			// Изменение возвращённой копии не должно портить кэш
+			found.OriginalURL = "https://mutated.example.com"
+		}
+
+		if calls := counting.calls.Load(); calls != 1 {
+			t.Fatalf("expected 1 repository call, got %d", calls)
+		}
+		found, _ := cached.GetByHash(t.Context(), links[0].Hash)
+		if found.OriginalURL != "https://example.com" {
+			t.Fatalf("expected cached link to stay intact, got %q", found.OriginalURL)
+		}
+	})
+}
+
+func TestCacheRemembersMissingHashes(t *testing.T) {
+	forEachCache(t, func(t *testing.T, newCache cacheFactory) {
+		cached, counting, _ := newCachedRepository(t, newCache)
+
+		for i := 0; i < 2; i++ {
+			if _, err := cached.GetByHash(t.Context(), "missing"); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("expected ErrRecordNotFound, got %v", err)
+			}
+		}
+		if calls := counting.calls.Load(); calls != 1 {
+			t.Fatalf("expected 1 repository call, got %d", calls)
+		}
+	})
+}
+
+func TestCacheForgetsMissAfterCreate(t *testing.T) {
+	forEachCache(t, func(t *testing.T, newCache cacheFactory) {
+		// Последовательные коды предсказуемы: hash будущей ссылки известен до её создания
+		repo := link.NewMemoryLinkRepository(link.NewSequentialGenerator(link.Base62Alphabet, link.DefaultHashLength, link.NewMemoryCodeCounter()))
+		hash, err := link.NewSequentialGenerator(link.Base62Alphabet, link.DefaultHashLength, link.NewMemoryCodeCounter()).Generate(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		cached := link.NewCachedLinkRepository(repo, newCache(t), cacheConfig)
+		bus := event.NewEventBus()
+		bus.On(event.LinkCreated, cached.HandleEvent)
+
+		if _, err := cached.GetByHash(t.Context(), hash); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("expected ErrRecordNotFound before create, got %v", err)
+		}
+		created, err := cached.Create(t.Context(), link.NewLink("https://example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.Hash != hash {
+			t.Fatalf("expected predicted hash %q, got %q", hash, created.Hash)
+		}
+		bus.Publish(event.NewEvent(t.Context(), event.LinkCreated, event.LinkChanged{ID: created.ID, Hashes: []string{created.Hash}}))
+
+		found, err := cached.GetByHash(t.Context(), hash)
+		if err != nil {
+			t.Fatalf("expected created link after link.created, got %v", err)
+		}
+		if found.ID != created.ID {
+			t.Fatalf("expected link %d, got %d", created.ID, found.ID)
+		}
+	})
+}
+
+func TestCacheInvalidatedByEvents(t *testing.T) {
+	forEachCache(t, func(t *testing.T, newCache cacheFactory) {
+		cached, _, links := newCachedRepository(t, newCache, "https://example.com")
+		bus := event.NewEventBus()
+		bus.On(event.LinkUpdated, cached.HandleEvent)
+		bus.On(event.LinkDeleted, cached.HandleEvent)
+
+		if _, err := cached.GetByHash(t.Context(), links[0].Hash); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cached.Update(t.Context(), &link.Link{Model: gorm.Model{ID: links[0].ID}, OriginalURL: "https://example.org"}); err != nil {
+			t.Fatal(err)
+		}
+		bus.Publish(event.NewEvent(t.Context(), event.LinkUpdated, event.LinkChanged{ID: links[0].ID, Hashes: []string{links[0].Hash}}))
+
+		found, err := cached.GetByHash(t.Context(), links[0].Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.OriginalURL != "https://example.org" {
+			t.Fatalf("expected updated URL after link.updated, got %q", found.OriginalURL)
+		}
+
+		if err := cached.Delete(t.Context(), links[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		bus.Publish(event.NewEvent(t.Context(), event.LinkDeleted, event.LinkChanged{ID: links[0].ID, Hashes: []string{links[0].Hash}}))
+
+		if _, err := cached.GetByHash(t.Context(), links[0].Hash); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("expected ErrRecordNotFound after link.deleted, got %v", err)
+		}
+	})
+}
+
+func TestCacheCollapsesConcurrentMisses(t *testing.T) {
+	forEachCache(t, func(t *testing.T, newCache cacheFactory) {
+		cached, counting, links := newCachedRepository(t, newCache, "https://example.com")
+		counting.release = make(chan struct{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := cached.GetByHash(context.Background(), links[0].Hash); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+
+		// Ждём, пока первый запрос дойдёт до репозитория, и даём остальным присоединиться к нему
+		for counting.calls.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(counting.release)
+		wg.Wait()
+
+		if calls := counting.calls.Load(); calls != 1 {
+			t.Fatalf("expected concurrent misses to share 1 repository call, got %d", calls)
+		}
+	})
+}
+
+func TestCacheInvalidateAll(t *testing.T) {
+	forEachCache(t, func(t *testing.T, newCache cacheFactory) {
+		cached, counting, links := newCachedRepository(t, newCache, "https://example.com/1", "https://example.com/2")
+
+		for _, l := range links {
+			if _, err := cached.GetByHash(t.Context(), l.Hash); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cached.InvalidateAll()
+		for _, l := range links {
+			if _, err := cached.GetByHash(t.Context(), l.Hash); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if calls := counting.calls.Load(); calls != 4 {
+			t.Fatalf("expected every link to be reloaded after InvalidateAll, got %d calls", calls)
+		}
+	})
+}
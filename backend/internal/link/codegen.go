@@ -0,0 +1,337 @@
+package link
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"fmt"
+	"linkshortener/config"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+const Base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Словарь стратегии pronounceable: короткие общеупотребительные английские слова в 3 и 4 буквы,
+// из которых составляется код любой длины от 6
+//
+//go:embed words.txt
+var pronounceableWordList string
+
+var pronounceableWords = wordsByLength(pronounceableWordList)
+
+// Сколько значений счётчика инстанс резервирует за одно обращение к хранилищу.
+// Неиспользованный остаток пропадает при перезапуске, оставляя пропуск в нумерации
+const codeCounterBlock = 100
+
+// Стратегия генерации коротких кодов. Generate вызывается на каждого кандидата,
+// в том числе для пула и при повторе вставки после коллизии
+type CodeGenerator interface {
+	Generate(ctx context.Context) (string, error)
+	// Сколько кодов уже израсходовано: стратегии на счётчике отвечают значением счётчика,
+	// включая зарезервированные блоки, а случайным своего учёта не нужно, и они считают занятые hash
+	Taken(ctx context.Context, hashes HashCounter) (int64, error)
+	// Вероятность, что следующий код совпадёт с одним из taken уже выданных
+	CollisionProbability(taken int64) float64
+}
+
+// Общий для всех инстансов счётчик кодов: Reserve возвращает первое из size значений,
+// которые больше никому не будут выданы, Current — последнее зарезервированное
+type CodeCounter interface {
+	Reserve(ctx context.Context, size uint64) (first uint64, err error)
+	Current(ctx context.Context) (uint64, error)
+}
+
+// Источник числа занятых hash для случайных стратегий, обычно репозиторий ссылок
+type HashCounter interface {
+	GetHashesCount(ctx context.Context) (int64, error)
+}
+
+// Выбирает стратегию из настроек инстанса; длина кода — HashLength. counter нужен стратегиям на счётчике
+func NewCodeGenerator(cfg config.LinkConfig, counter CodeCounter) (CodeGenerator, error) {
+	switch cfg.Code.Strategy {
+	case config.CodeStrategyRandom:
+		return NewRandomGenerator(cfg.Code.Alphabet, cfg.HashLength), nil
+	case config.CodeStrategyCounter:
+		return NewCounterGenerator(cfg.Code.Alphabet, cfg.HashLength, cfg.Code.Salt, counter), nil
+	case config.CodeStrategyPronounceable:
+		return NewPronounceableGenerator(cfg.HashLength), nil
+	case config.CodeStrategySequential:
+		return NewSequentialGenerator(cfg.Code.Alphabet, cfg.HashLength, counter), nil
+	}
+	return nil, fmt.Errorf("unknown code strategy %q", cfg.Code.Strategy)
+}
+
+// Случайные символы алфавита, например без похожих 0OIl
+type RandomGenerator struct {
+	alphabet string
+	length   int
+}
+
+func NewRandomGenerator(alphabet string, length int) *RandomGenerator {
+	return &RandomGenerator{alphabet: alphabet, length: length}
+}
+
+func (g *RandomGenerator) Generate(ctx context.Context) (string, error) {
+	return randomString(g.alphabet, g.length), nil
+}
+
+func (g *RandomGenerator) Taken(ctx context.Context, hashes HashCounter) (int64, error) {
+	return hashes.GetHashesCount(ctx)
+}
+
+func (g *RandomGenerator) CollisionProbability(taken int64) float64 {
+	return collisionProbability(taken, math.Pow(float64(len(g.alphabet)), float64(g.length)))
+}
+
+// Слова из словаря подряд: «sunbakeowl». Код длины length собирается из слов так, что все возможные
+// сочетания равновероятны; их меньше, чем случайных символов той же длины, поэтому коды длиннее 8
+// заметно снижают вероятность коллизий
+type PronounceableGenerator struct {
+	length int
+	// combinations[n] — число разных сочетаний слов общей длины n
+	combinations []*big.Int
+}
+
+func NewPronounceableGenerator(length int) *PronounceableGenerator {
+	combinations := make([]*big.Int, length+1)
+	combinations[0] = big.NewInt(1)
+	for n := 1; n <= length; n++ {
+		combinations[n] = new(big.Int)
+		for size, words := range pronounceableWords {
+			if len(words) > 0 && size <= n {
+				combinations[n].Add(combinations[n], new(big.Int).Mul(big.NewInt(int64(len(words))), combinations[n-size]))
+			}
+		}
+	}
+	return &PronounceableGenerator{length: length, combinations: combinations}
+}
+
+// Первое слово выбирается с весом по числу сочетаний, которые можно достроить после него
+func (g *PronounceableGenerator) Generate(ctx context.Context) (string, error) {
+	if g.combinations[g.length].Sign() == 0 {
+		return "", fmt.Errorf("no word combination has length %d", g.length)
+	}
+
+	var code strings.Builder
+	for rest := g.length; rest > 0; {
+		pick, err := rand.Int(rand.Reader, g.combinations[rest])
+		if err != nil {
+			return "", err
+		}
+		for size, words := range pronounceableWords {
+			if len(words) == 0 || size > rest {
+				continue
+			}
+			tails := g.combinations[rest-size]
+			weight := new(big.Int).Mul(big.NewInt(int64(len(words))), tails)
+			if pick.Cmp(weight) < 0 {
+				code.WriteString(words[new(big.Int).Div(pick, tails).Int64()])
+				rest -= size
+				break
+			}
+			pick.Sub(pick, weight)
+		}
+	}
+	return code.String(), nil
+}
+
+func (g *PronounceableGenerator) Taken(ctx context.Context, hashes HashCounter) (int64, error) {
+	return hashes.GetHashesCount(ctx)
+}
+
+func (g *PronounceableGenerator) CollisionProbability(taken int64) float64 {
+	space, _ := new(big.Float).SetInt(g.combinations[g.length]).Float64()
+	return collisionProbability(taken, space)
+}
+
+// Номер по порядку в алфавите без дополнения: 1, 2, … — самые короткие коды, но их легко перебрать
+type SequentialGenerator struct {
+	alphabet string
+	length   int
+	counter  counterBlock
+}
+
+func NewSequentialGenerator(alphabet string, length int, counter CodeCounter) *SequentialGenerator {
+	return &SequentialGenerator{alphabet: alphabet, length: length, counter: counterBlock{counter: counter}}
+}
+
+func (g *SequentialGenerator) Generate(ctx context.Context) (string, error) {
+	x, err := g.counter.next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return encode(new(big.Int).SetUint64(x), g.alphabet, 0), nil
+}
+
+func (g *SequentialGenerator) Taken(ctx context.Context, hashes HashCounter) (int64, error) {
+	return g.counter.taken(ctx)
+}
+
+// Счётчик уникален, пока не исчерпаны коды длины не больше length
+func (g *SequentialGenerator) CollisionProbability(taken int64) float64 {
+	return exhausted(taken, math.Pow(float64(len(g.alphabet)), float64(g.length)))
+}
+
+// Счётчик, перемешанный по соли, как в Sqids/Hashids: номер переводится биекцией
+// x → (a·x + b) mod N, где N — число кодов длины length, и записывается перемешанным алфавитом.
+// Коды уникальны, пока счётчик меньше N, и не выдают порядок создания при беглом взгляде,
+// но это обфускация, а не защита от подбора
+type CounterGenerator struct {
+	alphabet string
+	length   int
+	space    *big.Int
+	a, b     *big.Int
+	counter  counterBlock
+}
+
+func NewCounterGenerator(alphabet string, length int, salt string, counter CodeCounter) *CounterGenerator {
+	space := new(big.Int).Exp(big.NewInt(int64(len(alphabet))), big.NewInt(int64(length)), nil)
+	digest := sha256.Sum256([]byte(salt))
+
+	a := new(big.Int).SetBytes(digest[:16])
+	a.Mod(a, space)
+	// Множитель должен быть взаимно прост с N, иначе отображение не биекция
+	for one := big.NewInt(1); new(big.Int).GCD(nil, nil, a, space).Cmp(one) != 0; {
+		a.Add(a, one)
+	}
+	b := new(big.Int).SetBytes(digest[16:])
+	b.Mod(b, space)
+
+	return &CounterGenerator{
+		alphabet: shuffle(alphabet, digest),
+		length:   length,
+		space:    space,
+		a:        a,
+		b:        b,
+		counter:  counterBlock{counter: counter},
+	}
+}
+
+func (g *CounterGenerator) Generate(ctx context.Context) (string, error) {
+	next, err := g.counter.next(ctx)
+	if err != nil {
+		return "", err
+	}
+	x := new(big.Int).SetUint64(next)
+	x.Mul(x, g.a).Add(x, g.b).Mod(x, g.space)
+	return encode(x, g.alphabet, g.length), nil
+}
+
+func (g *CounterGenerator) Taken(ctx context.Context, hashes HashCounter) (int64, error) {
+	return g.counter.taken(ctx)
+}
+
+func (g *CounterGenerator) CollisionProbability(taken int64) float64 {
+	space, _ := new(big.Float).SetInt(g.space).Float64()
+	return exhausted(taken, space)
+}
+
+// Для равномерно случайных кодов: доля занятых в пространстве space
+func collisionProbability(taken int64, space float64) float64 {
+	return min(float64(taken)/space, 1)
+}
+
+func exhausted(taken int64, space float64) float64 {
+	if float64(taken) >= space {
+		return 1
+	}
+	return 0
+}
+
+// Значения общего счётчика, зарезервированные инстансом блоком по codeCounterBlock:
+// инстансы не выдают одинаковых кодов, а хранилище не нагружается на каждый код
+type counterBlock struct {
+	counter CodeCounter
+
+	mu           sync.Mutex
+	current, end uint64
+}
+
+func (b *counterBlock) next(ctx context.Context) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.current == b.end {
+		first, err := b.counter.Reserve(ctx, codeCounterBlock)
+		if err != nil {
+			return 0, fmt.Errorf("failed to reserve short codes: %w", err)
+		}
+		b.current, b.end = first, first+codeCounterBlock
+	}
+	next := b.current
+	b.current++
+	return next, nil
+}
+
+func (b *counterBlock) taken(ctx context.Context) (int64, error) {
+	current, err := b.counter.Current(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(min(current, math.MaxInt64)), nil
+}
+
+// Запись числа в алфавите; width > 0 дополняет код первым символом алфавита слева
+func encode(x *big.Int, alphabet string, width int) string {
+	base := big.NewInt(int64(len(alphabet)))
+	x = new(big.Int).Set(x)
+	digit := new(big.Int)
+
+	var code []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, base, digit)
+		code = append(code, alphabet[digit.Int64()])
+	}
+	for len(code) < width {
+		code = append(code, alphabet[0])
+	}
+	for i, j := 0, len(code)-1; i < j; i, j = i+1, j-1 {
+		code[i], code[j] = code[j], code[i]
+	}
+	return string(code)
+}
+
+// Детерминированная перестановка алфавита по дайджесту соли (Фишер — Йетс)
+func shuffle(alphabet string, digest [sha256.Size]byte) string {
+	shuffled := []byte(alphabet)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := int(digest[i%len(digest)]) % (i + 1)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return string(shuffled)
+}
+
+// Равномерный выбор символов: байты, попадающие в неполный хвост диапазона, отбрасываются
+func randomString(alphabet string, length int) string {
+	limit := 256 - 256%len(alphabet)
+	result := make([]byte, 0, length)
+	buf := make([]byte, length+length/2)
+
+	for len(result) < length {
+		if _, err := rand.Read(buf); err != nil {
+			panic("failed to generate random number: " + err.Error())
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(result) < length {
+				result = append(result, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return string(result)
+}
+
+// Индекс — длина слова; порядок слов в словаре сохраняется
+func wordsByLength(list string) [][]string {
+	var byLength [][]string
+	for _, word := range strings.Fields(list) {
+		for len(byLength) <= len(word) {
+			byLength = append(byLength, nil)
+		}
+		byLength[len(word)] = append(byLength[len(word)], word)
+	}
+	return byLength
+}
@@ -0,0 +1,182 @@
+package link_test
+
+import (
+	"context"
+	"linkshortener/config"
+	"linkshortener/internal/link"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRandomGeneratorUsesAlphabet(t *testing.T) {
+	alphabet := "23456789ABCDEFGHJKMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz"
+	generator := link.NewRandomGenerator(alphabet, 8)
+
+	for range 100 {
+		code := generate(t, generator)
+		if len(code) != 8 || strings.Trim(code, alphabet) != "" {
+			t.Fatalf("unexpected code %q", code)
+		}
+	}
+
+	half := int64(math.Pow(float64(len(alphabet)), 8) / 2)
+	if p := generator.CollisionProbability(half); math.Abs(p-0.5) > 1e-9 {
+		t.Fatalf("expected probability 0.5 with half of the codes taken, got %v", p)
+	}
+}
+
+func TestPronounceableGeneratorCombinesWords(t *testing.T) {
+	list, err := os.ReadFile("words.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(string(list)) {
+		words[word] = true
+	}
+	// Код разбивается на слова словаря, если разбивается его начало длины 3 или 4 и остаток
+	var fromWords func(code string) bool
+	fromWords = func(code string) bool {
+		if code == "" {
+			return true
+		}
+		for _, size := range []int{3, 4} {
+			if size <= len(code) && words[code[:size]] && fromWords(code[size:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for length := 6; length <= 12; length++ {
+		generator := link.NewPronounceableGenerator(length)
+		seen := make(map[string]bool)
+		for range 50 {
+			code := generate(t, generator)
+			if len(code) != length || !fromWords(code) {
+				t.Fatalf("length %d: expected code made of dictionary words, got %q", length, code)
+			}
+			seen[code] = true
+		}
+		if len(seen) < 40 {
+			t.Fatalf("length %d: expected varied codes, got %d distinct of 50", length, len(seen))
+		}
+	}
+
+	if short, long := link.NewPronounceableGenerator(6).CollisionProbability(1000), link.NewPronounceableGenerator(12).CollisionProbability(1000); short <= long || long == 0 {
+		t.Fatalf("expected longer codes to collide less, got %v and %v", short, long)
+	}
+	if _, err := link.NewPronounceableGenerator(5).Generate(t.Context()); err == nil {
+		t.Fatal("expected error when no word combination has the requested length")
+	}
+}
+
+func generate(t *testing.T, generator link.CodeGenerator) string {
+	t.Helper()
+	code, err := generator.Generate(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// Счётчик, уже отдавший 61 значение, например другому инстансу
+type offsetCounter struct {
+	link.MemoryCodeCounter
+	reserved []uint64
+}
+
+func (c *offsetCounter) Reserve(ctx context.Context, size uint64) (uint64, error) {
+	c.reserved = append(c.reserved, size)
+	if len(c.reserved) == 1 {
+		c.MemoryCodeCounter.Reserve(ctx, 61)
+	}
+	return c.MemoryCodeCounter.Reserve(ctx, size)
+}
+
+func TestSequentialGeneratorReservesBlocks(t *testing.T) {
+	counter := &offsetCounter{}
+	generator := link.NewSequentialGenerator(link.Base62Alphabet, 6, counter)
+	if first, second := generate(t, generator), generate(t, generator); first != "10" || second != "11" {
+		t.Fatalf("expected 10 and 11 after 61 taken values, got %q and %q", first, second)
+	}
+	for range 200 {
+		generate(t, generator)
+	}
+	if len(counter.reserved) != 3 {
+		t.Fatalf("expected counter to be reserved in blocks, got %v", counter.reserved)
+	}
+}
+
+func TestCounterGeneratorFailsWhenCounterIsUnavailable(t *testing.T) {
+	generator := link.NewCounterGenerator(link.Base62Alphabet, 6, "salt", link.NewMemoryCodeCounter())
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := generator.Generate(ctx); err == nil {
+		t.Fatal("expected error when counter cannot be reserved")
+	}
+}
+
+func TestCounterGeneratorIsUniqueAndSalted(t *testing.T) {
+	generator := link.NewCounterGenerator(link.Base62Alphabet, 6, "salt", link.NewMemoryCodeCounter())
+	same := link.NewCounterGenerator(link.Base62Alphabet, 6, "salt", link.NewMemoryCodeCounter())
+	other := link.NewCounterGenerator(link.Base62Alphabet, 6, "pepper", link.NewMemoryCodeCounter())
+
+	seen := make(map[string]bool)
+	for range 10000 {
+		code := generate(t, generator)
+		if len(code) != 6 || seen[code] {
+			t.Fatalf("unexpected or repeated code %q", code)
+		}
+		seen[code] = true
+	}
+
+	if a, b := generate(t, same), generate(t, other); a == b {
+		t.Fatalf("expected different salts to give different codes, got %q", a)
+	}
+	if p := generator.CollisionProbability(1_000_000); p != 0 {
+		t.Fatalf("expected no collisions before the counter wraps, got %v", p)
+	}
+}
+
+type hashCount int64
+
+func (c hashCount) GetHashesCount(ctx context.Context) (int64, error) {
+	return int64(c), nil
+}
+
+func TestGeneratorsReportTakenCodes(t *testing.T) {
+	counter := &offsetCounter{}
+	sequential := link.NewSequentialGenerator(link.Base62Alphabet, 6, counter)
+	generate(t, sequential)
+	// Счётчик учитывает и чужие значения, и весь зарезервированный блок, а не число ссылок
+	if taken, err := sequential.Taken(t.Context(), hashCount(5)); err != nil || taken != 161 {
+		t.Fatalf("sequential: expected counter value 161, got %d %v", taken, err)
+	}
+
+	random := link.NewRandomGenerator(link.Base62Alphabet, 6)
+	if taken, err := random.Taken(t.Context(), hashCount(5)); err != nil || taken != 5 {
+		t.Fatalf("random: expected 5 taken hashes, got %d %v", taken, err)
+	}
+}
+
+func TestNewCodeGenerator(t *testing.T) {
+	cfg := config.Default().Link
+	for _, strategy := range []string{config.CodeStrategyRandom, config.CodeStrategyCounter, config.CodeStrategyPronounceable, config.CodeStrategySequential} {
+		cfg.Code.Strategy = strategy
+		generator, err := link.NewCodeGenerator(cfg, link.NewMemoryCodeCounter())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := generate(t, generator); code == "" || len(code) > cfg.HashLength {
+			t.Fatalf("%s: unexpected code %q", strategy, code)
+		}
+	}
+
+	cfg.Code.Strategy = "unknown"
+	if _, err := link.NewCodeGenerator(cfg, link.NewMemoryCodeCounter()); err == nil {
+		t.Fatal("expected error for unknown strategy")
+	}
+}
@@ -0,0 +1,126 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"linkshortener/pkg/metrics"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Размер пачки кандидатов, проверяемых одним запросом
+	codePoolRefillBatch = 500
+	codePoolRefillTime  = 30 * time.Second
+)
+
+var errNoFreeCodes = errors.New("no free short codes in generated batch")
+
+// Пул заранее проверенных свободных hash: Next отдаёт код без обращения к БД, а когда пул
+// опустошается наполовину, он пополняется в фоне. Свободный при проверке код всё равно может
+// оказаться занят к моменту вставки (другой инстанс, гонка), поэтому вставка повторяется
+// при нарушении уникальности
+type CodePool struct {
+	codes    chan string
+	generate func(ctx context.Context) (string, error)
+	// Возвращает коды из списка, которых ещё нет в хранилище
+	free func(ctx context.Context, codes []string) ([]string, error)
+
+	refilling atomic.Bool
+	closed    atomic.Bool
+	wg        sync.WaitGroup
+}
+
+func NewCodePool(size int, generate func(ctx context.Context) (string, error), free func(ctx context.Context, codes []string) ([]string, error)) *CodePool {
+	return &CodePool{
+		codes:    make(chan string, size),
+		generate: generate,
+		free:     free,
+	}
+}
+
+// Если пул пуст, возвращает непроверенный код и запускает пополнение
+func (p *CodePool) Next(ctx context.Context) (string, error) {
+	select {
+	case code := <-p.codes:
+		if len(p.codes) < cap(p.codes)/2 {
+			p.refill()
+		}
+		return code, nil
+	default:
+		metrics.CodePoolMisses.Inc()
+		p.refill()
+		return p.generate(ctx)
+	}
+}
+
+func (p *CodePool) Len() int {
+	return len(p.codes)
+}
+
+// Останавливает пополнение и ждёт завершения запущенного
+func (p *CodePool) Close() {
+	p.closed.Store(true)
+	p.wg.Wait()
+}
+
+func (p *CodePool) refill() {
+	if p.closed.Load() || !p.refilling.CompareAndSwap(false, true) {
+		return
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer p.refilling.Store(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), codePoolRefillTime)
+		defer cancel()
+		if err := p.fill(ctx); err != nil {
+			slog.Error("failed to refill short code pool", "error", err)
+		}
+	}()
+}
+
+func (p *CodePool) fill(ctx context.Context) error {
+	for missing := cap(p.codes) - len(p.codes); missing > 0 && !p.closed.Load(); missing = cap(p.codes) - len(p.codes) {
+		candidates, err := p.candidates(ctx, min(missing, codePoolRefillBatch))
+		if err != nil {
+			return err
+		}
+		free, err := p.free(ctx, candidates)
+		if err != nil {
+			return err
+		}
+		if len(free) == 0 {
+			return errNoFreeCodes
+		}
+		for _, code := range free {
+			select {
+			case p.codes <- code:
+			default:
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (p *CodePool) candidates(ctx context.Context, n int) ([]string, error) {
+	seen := make(map[string]struct{}, n)
+	codes := make([]string, 0, n)
+	// Число попыток ограничено на случай, если пространство кодов меньше n
+	for attempt := 0; len(codes) < n && attempt < 2*n; attempt++ {
+		code, err := p.generate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[code]; ok {
+			continue
+		}
+		seen[code] = struct{}{}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
@@ -0,0 +1,91 @@
+package link_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"linkshortener/internal/link"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Генератор с предсказуемыми кодами code-1, code-2, ...
+type sequence struct {
+	mu   sync.Mutex
+	next int
+}
+
+func (s *sequence) generate(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	return fmt.Sprintf("code-%d", s.next), nil
+}
+
+func next(t *testing.T, pool *link.CodePool) string {
+	t.Helper()
+	code, err := pool.Next(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestCodePoolSkipsTakenCodes(t *testing.T) {
+	taken := map[string]bool{"code-2": true, "code-3": true}
+	var checks int
+	pool := link.NewCodePool(4, (&sequence{}).generate, func(ctx context.Context, codes []string) ([]string, error) {
+		checks++
+		return slices.DeleteFunc(codes, func(code string) bool { return taken[code] }), nil
+	})
+	t.Cleanup(pool.Close)
+
+	// Пул ещё пуст: код выдаётся без проверки, а пополнение запускается в фоне
+	if code := next(t, pool); code != "code-1" {
+		t.Fatalf("expected unchecked code-1, got %q", code)
+	}
+	waitFull(t, pool, 4)
+
+	var codes []string
+	for range 4 {
+		codes = append(codes, next(t, pool))
+	}
+	for _, code := range codes {
+		if taken[code] {
+			t.Fatalf("pool handed out taken code %q: %v", code, codes)
+		}
+	}
+	if checks == 0 {
+		t.Fatal("expected candidates to be checked")
+	}
+}
+
+func TestCodePoolFallsBackWhenCheckFails(t *testing.T) {
+	pool := link.NewCodePool(4, (&sequence{}).generate, func(ctx context.Context, codes []string) ([]string, error) {
+		return nil, errors.New("database is down")
+	})
+	t.Cleanup(pool.Close)
+
+	first, second := next(t, pool), next(t, pool)
+	if first == "" || second == "" || first == second {
+		t.Fatalf("expected distinct unchecked codes, got %q and %q", first, second)
+	}
+	pool.Close()
+	if pool.Len() != 0 {
+		t.Fatalf("expected pool to stay empty, got %d codes", pool.Len())
+	}
+}
+
+func waitFull(t *testing.T, pool *link.CodePool, size int) {
+	t.Helper()
+	// Close дожидается фонового пополнения, но останавливает следующие, поэтому ждём опросом
+	for range 1000 {
+		if pool.Len() == size {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("pool was not refilled: %d of %d codes", pool.Len(), size)
+}
@@ -0,0 +1,87 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"linkshortener/pkg/db"
+	"sync"
+)
+
+// Имя счётчика коротких кодов в таблице code_counters
+const linkCodeCounter = "links"
+
+var errCodeCounterMissing = errors.New("code counter is missing, run migrations")
+
+// Счётчик в таблице code_counters: блок выделяется одним UPDATE ... RETURNING,
+// поэтому инстансы получают непересекающиеся диапазоны без отдельной блокировки
+type CodeCounterRepository struct {
+	db *db.Db
+}
+
+func NewCodeCounterRepository(db *db.Db) *CodeCounterRepository {
+	return &CodeCounterRepository{db: db}
+}
+
+func (repo *CodeCounterRepository) Reserve(ctx context.Context, size uint64) (uint64, error) {
+	tx, cancel := repo.db.Query(db.WithPrimary(ctx))
+	defer cancel()
+
+	var last []uint64
+	result := tx.Raw(
+		"UPDATE code_counters SET value = value + ? WHERE name = ? RETURNING value",
+		size, linkCodeCounter,
+	).Scan(&last)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if len(last) == 0 {
+		return 0, errCodeCounterMissing
+	}
+	return last[0] - size + 1, nil
+}
+
+func (repo *CodeCounterRepository) Current(ctx context.Context) (uint64, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var value []uint64
+	result := tx.Raw("SELECT value FROM code_counters WHERE name = ?", linkCodeCounter).Scan(&value)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if len(value) == 0 {
+		return 0, errCodeCounterMissing
+	}
+	return value[0], nil
+}
+
+// Счётчик в памяти процесса для драйвера memory, где хранилище и так не общее
+type MemoryCodeCounter struct {
+	mu    sync.Mutex
+	value uint64
+}
+
+func NewMemoryCodeCounter() *MemoryCodeCounter {
+	return &MemoryCodeCounter{}
+}
+
+func (c *MemoryCodeCounter) Reserve(ctx context.Context, size uint64) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	first := c.value + 1
+	c.value += size
+	return first, nil
+}
+
+func (c *MemoryCodeCounter) Current(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value, nil
+}
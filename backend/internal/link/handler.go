@@ -1,9 +1,12 @@
 package link
 
 import (
+	"errors"
 	"linkshortener/config"
+	"linkshortener/internal/audit"
 	"linkshortener/pkg/di"
 	"linkshortener/pkg/event"
+	"linkshortener/pkg/metrics"
 	"linkshortener/pkg/middleware"
 	"linkshortener/pkg/req"
 	"linkshortener/pkg/res"
@@ -15,8 +18,10 @@ import (
 
 type LinkHandlerDeps struct {
 	Config         *config.Config
-	LinkRepository *LinkRepository
+	LinkRepository di.ILinkRepository[Link]
+	UserRepository di.IUserRepository
 	EventBus       di.IEventBus
+	AuditLogger    di.IAuditLogger
 }
 
 type LinkHandler struct {
@@ -28,24 +33,33 @@ func NewLinkHandler(router *http.ServeMux, deps *LinkHandlerDeps) {
 		deps: deps,
 	}
 	router.HandleFunc("GET /link/{hash}", linkHandler.GoTo())
-	router.Handle("POST /link", middleware.IsAuthenticated(linkHandler.Create(), deps.Config))
-	router.Handle("PATCH /link/{id}", middleware.IsAuthenticated(linkHandler.Update(), deps.Config))
-	router.Handle("DELETE /link/{id}", middleware.IsAuthenticated(linkHandler.Delete(), deps.Config))
-	router.Handle("GET /link", middleware.IsAuthenticated(linkHandler.GetLinks(), deps.Config))
+	router.Handle("POST /link", middleware.IsAuthenticated(linkHandler.Create(), deps.Config, deps.UserRepository))
+	router.Handle("PATCH /link/{id}", middleware.IsAuthenticated(linkHandler.Update(), deps.Config, deps.UserRepository))
+	router.Handle("DELETE /link/{id}", middleware.IsAuthenticated(linkHandler.Delete(), deps.Config, deps.UserRepository))
+	router.Handle("GET /link", middleware.IsAuthenticated(linkHandler.GetLinks(), deps.Config, deps.UserRepository))
 }
 
 func (handler *LinkHandler) GoTo() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		hash := r.PathValue("hash")
-		link, err := handler.deps.LinkRepository.GetByHash(hash)
+		link, err := handler.deps.LinkRepository.GetByHash(r.Context(), hash)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			metrics.Redirects.WithLabelValues("not_found").Inc()
+			res.Error(w, r, http.StatusNotFound, res.CodeLinkNotFound)
+			return
+		}
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			metrics.Redirects.WithLabelValues("error").Inc()
+			res.InternalError(w, r, err)
 			return
 		}
-		go handler.deps.EventBus.Publish(event.Event{
-			Type: event.LinkClicked,
-			Data: link.ID,
-		})
+		if link.Disabled {
+			metrics.Redirects.WithLabelValues("disabled").Inc()
+			res.Error(w, r, http.StatusGone, res.CodeLinkDisabled)
+			return
+		}
+		handler.deps.EventBus.Publish(event.NewEvent(r.Context(), event.LinkClicked, link.ID))
+		metrics.Redirects.WithLabelValues("ok").Inc()
 		http.Redirect(w, r, link.OriginalURL, http.StatusTemporaryRedirect)
 	}
 }
@@ -57,11 +71,19 @@ func (handler *LinkHandler) Create() http.HandlerFunc {
 			return
 		}
 		link := NewLink(body.URL)
-		createdLink, err := handler.deps.LinkRepository.Create(link)
+		if currentUser, ok := middleware.CurrentUser(r.Context()); ok {
+			link.UserID = &currentUser.ID
+		}
+		createdLink, err := handler.deps.LinkRepository.Create(r.Context(), link)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			res.InternalError(w, r, err)
 			return
 		}
+		handler.deps.EventBus.Publish(event.NewEvent(r.Context(), event.LinkCreated, event.LinkChanged{
+			ID:     createdLink.ID,
+			Hashes: []string{createdLink.Hash},
+		}))
+		handler.deps.AuditLogger.Log(r, audit.ActionLinkCreated, audit.Target("link", createdLink.ID), nil, createdLink)
 		res.Response(w, 201, createdLink)
 	}
 }
@@ -76,17 +98,36 @@ func (handler *LinkHandler) Update() http.HandlerFunc {
 
 		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidID)
+			return
+		}
+		before, err := handler.deps.LinkRepository.GetById(r.Context(), uint(id))
+		if err != nil {
+			linkNotFound(w, r, err)
 			return
 		}
-		link, err := handler.deps.LinkRepository.Update(&Link{
+
+		link, err := handler.deps.LinkRepository.Update(r.Context(), &Link{
 			Model: gorm.Model{
 				ID: uint(id),
 			},
 			OriginalURL: body.URL,
 			Hash:        body.Hash,
 		})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			res.Error(w, r, http.StatusConflict, res.CodeHashTaken)
+			return
+		}
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
 
+		handler.deps.EventBus.Publish(event.NewEvent(r.Context(), event.LinkUpdated, event.LinkChanged{
+			ID:     link.ID,
+			Hashes: []string{before.Hash, link.Hash},
+		}))
+		handler.deps.AuditLogger.Log(r, audit.ActionLinkUpdated, audit.Target("link", id), before, link)
 		res.Response(w, 200, link)
 	}
 }
@@ -95,43 +136,49 @@ func (handler *LinkHandler) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidID)
 			return
 		}
 
-		err = handler.deps.LinkRepository.Delete(uint(id))
+		before, err := handler.deps.LinkRepository.GetById(r.Context(), uint(id))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			linkNotFound(w, r, err)
 			return
 		}
 
+		err = handler.deps.LinkRepository.Delete(r.Context(), uint(id))
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+
+		handler.deps.EventBus.Publish(event.NewEvent(r.Context(), event.LinkDeleted, event.LinkChanged{
+			ID:     before.ID,
+			Hashes: []string{before.Hash},
+		}))
+		handler.deps.AuditLogger.Log(r, audit.ActionLinkDeleted, audit.Target("link", id), before, nil)
+
 		res.Response(w, 200, nil)
 	}
 }
 
 func (handler *LinkHandler) GetLinks() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		limit, offset, err := req.Pagination(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			res.Error(w, r, http.StatusBadRequest, res.CodeInvalidPagination)
 			return
 		}
 
-		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+		links, err := handler.deps.LinkRepository.GetLinks(r.Context(), limit, offset)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			res.InternalError(w, r, err)
 			return
 		}
 
-		links, err := handler.deps.LinkRepository.GetLinks(uint(limit), uint(offset))
+		count, err := handler.deps.LinkRepository.GetLinksCount(r.Context())
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		count, err := handler.deps.LinkRepository.GetLinksCount()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			res.InternalError(w, r, err)
 			return
 		}
 
@@ -141,3 +188,11 @@ func (handler *LinkHandler) GetLinks() http.HandlerFunc {
 		})
 	}
 }
+
+func linkNotFound(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		res.Error(w, r, http.StatusNotFound, res.CodeLinkNotFound)
+		return
+	}
+	res.InternalError(w, r, err)
+}
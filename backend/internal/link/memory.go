@@ -0,0 +1,230 @@
+package link
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Хранилище ссылок в памяти процесса (драйвер БД memory): для разработки и тестов без СУБД.
+// Операции мгновенные, поэтому контекст проверяется только на входе. Удаление мягкое, как в GORM: удалённая ссылка не находится, но её hash остаётся занятым
+type MemoryLinkRepository struct {
+	mu       sync.RWMutex
+	links    map[uint]*Link
+	hashes   map[string]uint
+	nextID   uint
+	generate CodeGenerator
+}
+
+func NewMemoryLinkRepository(generate CodeGenerator) *MemoryLinkRepository {
+	return &MemoryLinkRepository{
+		links:    make(map[uint]*Link),
+		hashes:   make(map[string]uint),
+		generate: generate,
+	}
+}
+
+func (repo *MemoryLinkRepository) GetByHash(ctx context.Context, hash string) (*Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	id, ok := repo.hashes[hash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return repo.get(id)
+}
+
+func (repo *MemoryLinkRepository) Create(ctx context.Context, link *Link) (*Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if attempt == maxCreateAttempts {
+			return nil, gorm.ErrDuplicatedKey
+		}
+		hash, err := repo.generate.Generate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		link.Hash = hash
+		if _, taken := repo.hashes[link.Hash]; !taken {
+			break
+		}
+	}
+
+	repo.nextID++
+	now := time.Now()
+	link.ID = repo.nextID
+	link.CreatedAt = now
+	link.UpdatedAt = now
+
+	stored := *link
+	stored.Stats = nil
+	repo.links[stored.ID] = &stored
+	repo.hashes[stored.Hash] = stored.ID
+	return link, nil
+}
+
+// Как Updates в GORM: меняются только непустые поля, в link возвращается вся запись
+func (repo *MemoryLinkRepository) Update(ctx context.Context, link *Link) (*Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.links[link.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	if link.Hash != "" && link.Hash != stored.Hash {
+		if _, taken := repo.hashes[link.Hash]; taken {
+			return nil, gorm.ErrDuplicatedKey
+		}
+		delete(repo.hashes, stored.Hash)
+		repo.hashes[link.Hash] = stored.ID
+		stored.Hash = link.Hash
+	}
+	if link.OriginalURL != "" {
+		stored.OriginalURL = link.OriginalURL
+	}
+	if link.Disabled {
+		stored.Disabled = true
+	}
+	stored.UpdatedAt = time.Now()
+
+	*link = *stored
+	return link, nil
+}
+
+func (repo *MemoryLinkRepository) GetById(ctx context.Context, id uint) (*Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.get(id)
+}
+
+func (repo *MemoryLinkRepository) FindById(ctx context.Context, id uint) error {
+	_, err := repo.GetById(ctx, id)
+	return err
+}
+
+func (repo *MemoryLinkRepository) Delete(ctx context.Context, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.links[id]
+	if !ok || stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (repo *MemoryLinkRepository) SetDisabled(ctx context.Context, id uint, disabled bool) (*Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.links[id]
+	if !ok || stored.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	stored.Disabled = disabled
+	stored.UpdatedAt = time.Now()
+
+	link := *stored
+	return &link, nil
+}
+
+func (repo *MemoryLinkRepository) GetLinks(ctx context.Context, limit, offset uint) ([]Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	links := repo.active()
+	slices.SortFunc(links, func(a, b Link) int { return int(b.ID) - int(a.ID) })
+
+	if offset >= uint(len(links)) {
+		return []Link{}, nil
+	}
+	links = links[offset:]
+	if limit < uint(len(links)) {
+		links = links[:limit]
+	}
+	return links, nil
+}
+
+func (repo *MemoryLinkRepository) GetLinksCount(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return int64(len(repo.active())), nil
+}
+
+func (repo *MemoryLinkRepository) GetHashesCount(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return int64(len(repo.hashes)), nil
+}
+
+func (repo *MemoryLinkRepository) GetUserLinkIds(ctx context.Context, userId uint) ([]uint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	linkIds := []uint{}
+	for id, stored := range repo.links {
+		if stored.UserID != nil && *stored.UserID == userId {
+			linkIds = append(linkIds, id)
+		}
+	}
+	slices.Sort(linkIds)
+	return linkIds, nil
+}
+
+func (repo *MemoryLinkRepository) get(id uint) (*Link, error) {
+	stored, ok := repo.links[id]
+	if !ok || stored.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	link := *stored
+	return &link, nil
+}
+
+func (repo *MemoryLinkRepository) active() []Link {
+	links := make([]Link, 0, len(repo.links))
+	for _, stored := range repo.links {
+		if !stored.DeletedAt.Valid {
+			links = append(links, *stored)
+		}
+	}
+	return links
+}
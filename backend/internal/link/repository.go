@@ -1,61 +1,149 @@
 package link
 
 import (
+	"context"
+	"errors"
 	"linkshortener/pkg/db"
+	"slices"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// Сколько раз Create подбирает новый hash при нарушении уникальности
+const maxCreateAttempts = 5
+
 type LinkRepository struct {
-	db *db.Db
+	db       *db.Db
+	generate CodeGenerator
+	// nil, если пул отключён (poolSize = 0): hash генерируется при вставке
+	codes *CodePool
+}
+
+func NewLinkRepository(db *db.Db, generate CodeGenerator, poolSize int) *LinkRepository {
+	repo := &LinkRepository{db: db, generate: generate}
+	if poolSize > 0 {
+		repo.codes = NewCodePool(poolSize, generate.Generate, repo.freeHashes)
+	}
+	return repo
 }
 
-func NewLinkRepository(db *db.Db) *LinkRepository {
-	return &LinkRepository{db: db}
+// Дожидается фонового пополнения пула hash; вызывается до закрытия БД
+func (repo *LinkRepository) Close() {
+	if repo.codes != nil {
+		repo.codes.Close()
+	}
 }
 
-func (repo *LinkRepository) GetByHash(hash string) (*Link, error) {
+func (repo *LinkRepository) GetByHash(ctx context.Context, hash string) (*Link, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
 	var link Link
-	result := repo.db.DB.Table("links").First(&link, "hash = ?", hash)
+	result := tx.Table("links").First(&link, "hash = ?", hash)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &link, nil
 }
 
-func (repo *LinkRepository) Create(link *Link) (*Link, error) {
-	link.Hash = CheckUniqueAndGenerateHash(repo.db)
+func (repo *LinkRepository) Create(ctx context.Context, link *Link) (*Link, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var err error
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		if link.Hash, err = repo.nextHash(ctx); err != nil {
+			return nil, err
+		}
+		err = tx.Table("links").Create(link).Error
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
 
-	result := repo.db.DB.Table("links").Create(link)
+func (repo *LinkRepository) nextHash(ctx context.Context) (string, error) {
+	if repo.codes == nil {
+		return repo.generate.Generate(ctx)
+	}
+	return repo.codes.Next(ctx)
+}
+
+// Проверяет кандидатов для пула одним запросом; удалённые ссылки тоже занимают hash
+func (repo *LinkRepository) freeHashes(ctx context.Context, hashes []string) ([]string, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var taken []string
+	result := tx.Unscoped().Model(&Link{}).Where("hash IN ?", hashes).Pluck("hash", &taken)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 
-	return link, nil
+	takenSet := make(map[string]struct{}, len(taken))
+	for _, hash := range taken {
+		takenSet[hash] = struct{}{}
+	}
+	return slices.DeleteFunc(hashes, func(hash string) bool {
+		_, ok := takenSet[hash]
+		return ok
+	}), nil
 }
 
-func (repo *LinkRepository) Update(link *Link) (*Link, error) {
-	result := repo.db.DB.Clauses(clause.Returning{}).Updates(link)
+func (repo *LinkRepository) Update(ctx context.Context, link *Link) (*Link, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	result := tx.Clauses(clause.Returning{}).Updates(link)
 	if result.Error != nil {
 		return nil, result.Error
 	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
 	return link, nil
 }
 
-func (repo *LinkRepository) FindById(id uint) error {
-	result := repo.db.DB.Table("links").Find(&Link{}, id)
+func (repo *LinkRepository) GetById(ctx context.Context, id uint) (*Link, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var link Link
+	result := tx.Table("links").First(&link, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &link, nil
+}
+
+func (repo *LinkRepository) FindById(ctx context.Context, id uint) error {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	result := tx.Table("links").Find(&Link{}, id)
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
 	return nil
 }
 
-func (repo *LinkRepository) Delete(id uint) error {
-	if err := repo.FindById(id); err != nil {
+func (repo *LinkRepository) Delete(ctx context.Context, id uint) error {
+	if err := repo.FindById(ctx, id); err != nil {
 		return err
 	}
 
-	result := repo.db.DB.Table("links").Delete(&Link{}, id)
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	result := tx.Table("links").Delete(&Link{}, id)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -63,10 +151,29 @@ func (repo *LinkRepository) Delete(id uint) error {
 	return nil
 }
 
-func (repo *LinkRepository) GetLinks(limit, offset uint) ([]Link, error) {
+func (repo *LinkRepository) SetDisabled(ctx context.Context, id uint, disabled bool) (*Link, error) {
+	link, err := repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	result := tx.Model(link).Update("disabled", disabled)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return link, nil
+}
+
+func (repo *LinkRepository) GetLinks(ctx context.Context, limit, offset uint) ([]Link, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
 	var links []Link
 
-	result := repo.db.DB.
+	result := tx.
 		Table("links").
 		Where("deleted_at IS NULL").
 		Order("id DESC").
@@ -80,15 +187,45 @@ func (repo *LinkRepository) GetLinks(limit, offset uint) ([]Link, error) {
 	return links, nil
 }
 
-func (repo *LinkRepository) GetLinksCount() (int64, error) {
+func (repo *LinkRepository) GetLinksCount(ctx context.Context) (int64, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
 	var count int64
-	result := repo.db.DB.
+	result := tx.
 		Table("links").
 		Where("deleted_at IS NULL").
 		Count(&count)
-		
+
 	if result.Error != nil {
 		return 0, result.Error
 	}
 	return count, nil
 }
+
+func (repo *LinkRepository) GetHashesCount(ctx context.Context) (int64, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var count int64
+	result := tx.
+		Table("links").
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+func (repo *LinkRepository) GetUserLinkIds(ctx context.Context, userId uint) ([]uint, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	linkIds := []uint{}
+	result := tx.Unscoped().Model(&Link{}).Where("user_id = ?", userId).Order("id").Pluck("id", &linkIds)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return linkIds, nil
+}
@@ -9,148 +9,27 @@ import (
 	"gorm.io/gorm"
 )
 
-type MockDB struct {
-	links     map[string]*link.Link
-	linksById map[uint]*link.Link
-	nextID    uint
-}
-
-func NewMockDB() *MockDB {
-	return &MockDB{
-		links:     make(map[string]*link.Link),
-		linksById: make(map[uint]*link.Link),
-		nextID:    1,
-	}
-}
-
-func (m *MockDB) First(dest interface{}, conds ...interface{}) *gorm.DB {
-	result := &gorm.DB{}
-
-	if linkPtr, ok := dest.(*link.Link); ok {
-		if len(conds) >= 2 {
-			if conds[0] == "hash = ?" {
-				hash := conds[1].(string)
-				if foundLink, exists := m.links[hash]; exists {
-					*linkPtr = *foundLink
-				} else {
-					result.Error = gorm.ErrRecordNotFound
-				}
-			}
-		} else if len(conds) == 1 {
-			// Поиск по ID
-			id := conds[0].(uint)
-			if foundLink, exists := m.linksById[id]; exists {
-				*linkPtr = *foundLink
-			} else {
-				result.Error = gorm.ErrRecordNotFound
-			}
-		}
-	}
-
-	return result
-}
-
-type MockLinkRepository struct {
-	db *MockDB
-}
-
-func NewMockLinkRepository() *MockLinkRepository {
-	return &MockLinkRepository{
-		db: NewMockDB(),
-	}
-}
-
-func (repo *MockLinkRepository) GetByHash(hash string) (*link.Link, error) {
-	if linkItem, exists := repo.db.links[hash]; exists {
-		return linkItem, nil
-	}
-	return nil, gorm.ErrRecordNotFound
-}
-
-func (repo *MockLinkRepository) Create(linkItem *link.Link) (*link.Link, error) {
-	linkItem.Hash = generateUniqueHash(repo.db)
-	linkItem.ID = repo.db.nextID
-	repo.db.nextID++
-
-	repo.db.links[linkItem.Hash] = linkItem
-	repo.db.linksById[linkItem.ID] = linkItem
-
-	return linkItem, nil
-}
-
-func (repo *MockLinkRepository) Update(linkItem *link.Link) (*link.Link, error) {
-	if existingLink, exists := repo.db.linksById[linkItem.ID]; exists {
-		existingLink.OriginalURL = linkItem.OriginalURL
-		existingLink.Hash = linkItem.Hash
-		repo.db.links[linkItem.Hash] = existingLink
-		return existingLink, nil
-	}
-	return nil, errors.New("link not found")
-}
-
-func (repo *MockLinkRepository) FindById(id uint) error {
-	if _, exists := repo.db.linksById[id]; exists {
-		return nil
-	}
-	return gorm.ErrRecordNotFound
-}
-
-func (repo *MockLinkRepository) Delete(id uint) error {
-	if err := repo.FindById(id); err != nil {
-		return err
-	}
-
-	linkItem := repo.db.linksById[id]
-	delete(repo.db.linksById, id)
-	delete(repo.db.links, linkItem.Hash)
-
-	return nil
-}
-
-func (repo *MockLinkRepository) GetLinks(limit, offset uint) ([]link.Link, error) {
-	links := make([]link.Link, 0)
-	count := uint(0)
-
-	for _, linkItem := range repo.db.linksById {
-		if count >= offset {
-			if uint(len(links)) >= limit {
-				break
-			}
-			links = append(links, *linkItem)
-		}
-		count++
-	}
-
-	return links, nil
-}
-
-func (repo *MockLinkRepository) GetLinksCount() (int64, error) {
-	return int64(len(repo.db.linksById)), nil
-}
-
-func generateUniqueHash(mockDB *MockDB) string {
-	for {
-		hash := link.GenerateHash()
-		if _, exists := mockDB.links[hash]; !exists {
-			return hash
+func newRepository(t *testing.T, urls ...string) (*link.MemoryLinkRepository, []*link.Link) {
+	t.Helper()
+	repo := link.NewMemoryLinkRepository(link.NewRandomGenerator(link.Base62Alphabet, link.DefaultHashLength))
+
+	links := make([]*link.Link, 0, len(urls))
+	for _, url := range urls {
+		created, err := repo.Create(t.Context(), link.NewLink(url))
+		if err != nil {
+			t.Fatal(err)
 		}
+		links = append(links, created)
 	}
+	return repo, links
 }
 
 func TestLinkRepositoryGetByHashSuccess(t *testing.T) {
 	godotenv.Load()
 
-	repo := NewMockLinkRepository()
+	repo, links := newRepository(t, "https://example.com")
 
-	testLink := &link.Link{
-		OriginalURL: "https://example.com",
-		Hash:        "test123",
-	}
-	testLink.ID = 1
-	repo.db.links["test123"] = testLink
-	repo.db.linksById[1] = testLink
-
-	linkItem, err := repo.GetByHash("test123")
+	linkItem, err := repo.GetByHash(t.Context(), links[0].Hash)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -168,15 +47,15 @@ func TestLinkRepositoryGetByHashSuccess(t *testing.T) {
 func TestLinkRepositoryGetByHashNotFound(t *testing.T) {
 	godotenv.Load()
 
-	repo := NewMockLinkRepository()
+	repo, _ := newRepository(t)
 
-	_, err := repo.GetByHash("nonexistent")
+	_, err := repo.GetByHash(t.Context(), "nonexistent")
 
 	if err == nil {
 		t.Fatal("Expected error for non-existent hash")
 	}
 
-	if err != gorm.ErrRecordNotFound {
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		t.Fatalf("Expected ErrRecordNotFound, got %v", err)
 	}
 }
@@ -184,11 +63,11 @@ func TestLinkRepositoryGetByHashNotFound(t *testing.T) {
 func TestLinkRepositoryCreateSuccess(t *testing.T) {
 	godotenv.Load()
 
-	repo := NewMockLinkRepository()
+	repo, _ := newRepository(t)
 
 	newLink := link.NewLink("https://google.com")
 
-	createdLink, err := repo.Create(newLink)
+	createdLink, err := repo.Create(t.Context(), newLink)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -214,23 +93,15 @@ func TestLinkRepositoryCreateSuccess(t *testing.T) {
 func TestLinkRepositoryUpdateSuccess(t *testing.T) {
 	godotenv.Load()
 
-	repo := NewMockLinkRepository()
-
-	testLink := &link.Link{
-		OriginalURL: "https://example.com",
-		Hash:        "test123",
-	}
-	testLink.ID = 1
-	repo.db.links["test123"] = testLink
-	repo.db.linksById[1] = testLink
+	repo, links := newRepository(t, "https://example.com")
 
 	updateLink := &link.Link{
 		OriginalURL: "https://updated.com",
 		Hash:        "updated123",
 	}
-	updateLink.ID = 1
+	updateLink.ID = links[0].ID
 
-	updatedLink, err := repo.Update(updateLink)
+	updatedLink, err := repo.Update(t.Context(), updateLink)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -248,23 +119,15 @@ func TestLinkRepositoryUpdateSuccess(t *testing.T) {
 func TestLinkRepositoryDeleteSuccess(t *testing.T) {
 	godotenv.Load()
 
-	repo := NewMockLinkRepository()
+	repo, links := newRepository(t, "https://example.com")
 
-	testLink := &link.Link{
-		OriginalURL: "https://example.com",
-		Hash:        "test123",
-	}
-	testLink.ID = 1
-	repo.db.links["test123"] = testLink
-	repo.db.linksById[1] = testLink
-
-	err := repo.Delete(1)
+	err := repo.Delete(t.Context(), links[0].ID)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	_, err = repo.GetByHash("test123")
+	_, err = repo.GetByHash(t.Context(), links[0].Hash)
 	if err == nil {
 		t.Fatal("Expected link to be deleted")
 	}
@@ -273,9 +136,9 @@ func TestLinkRepositoryDeleteSuccess(t *testing.T) {
 func TestLinkRepositoryDeleteNotFound(t *testing.T) {
 	godotenv.Load()
 
-	repo := NewMockLinkRepository()
+	repo, _ := newRepository(t)
 
-	err := repo.Delete(999)
+	err := repo.Delete(t.Context(), 999)
 
 	if err == nil {
 		t.Fatal("Expected error for non-existent link")
@@ -285,19 +148,15 @@ func TestLinkRepositoryDeleteNotFound(t *testing.T) {
 func TestLinkRepositoryGetLinksSuccess(t *testing.T) {
 	godotenv.Load()
 
-	repo := NewMockLinkRepository()
+	repo, _ := newRepository(t,
+		"https://example.com/1",
+		"https://example.com/2",
+		"https://example.com/3",
+		"https://example.com/4",
+		"https://example.com/5",
+	)
 
-	for i := 1; i <= 5; i++ {
-		linkItem := &link.Link{
-			OriginalURL: "https://example.com",
-			Hash:        "test" + string(rune(i)),
-		}
-		linkItem.ID = uint(i)
-		repo.db.links[linkItem.Hash] = linkItem
-		repo.db.linksById[uint(i)] = linkItem
-	}
-
-	links, err := repo.GetLinks(3, 1)
+	links, err := repo.GetLinks(t.Context(), 3, 1)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -311,19 +170,17 @@ func TestLinkRepositoryGetLinksSuccess(t *testing.T) {
 func TestLinkRepositoryGetLinksCountSuccess(t *testing.T) {
 	godotenv.Load()
 
-	repo := NewMockLinkRepository()
-
-	for i := 1; i <= 7; i++ {
-		linkItem := &link.Link{
-			OriginalURL: "https://example.com",
-			Hash:        "test" + string(rune(i)),
-		}
-		linkItem.ID = uint(i)
-		repo.db.links[linkItem.Hash] = linkItem
-		repo.db.linksById[uint(i)] = linkItem
-	}
-
-	count, err := repo.GetLinksCount()
+	repo, _ := newRepository(t,
+		"https://example.com/1",
+		"https://example.com/2",
+		"https://example.com/3",
+		"https://example.com/4",
+		"https://example.com/5",
+		"https://example.com/6",
+		"https://example.com/7",
+	)
+
+	count, err := repo.GetLinksCount(t.Context())
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"context"
+	"sync"
+)
+
+// Очередь кликов в памяти процесса: клики, не записанные до остановки, теряются.
+// Пачка не переживает процесс, поэтому Pop сразу убирает клики, а Ack возвращает только retry
+type MemoryClickBuffer struct {
+	mu     sync.Mutex
+	clicks []uint
+}
+
+func NewMemoryClickBuffer() *MemoryClickBuffer {
+	return &MemoryClickBuffer{}
+}
+
+func (b *MemoryClickBuffer) Push(ctx context.Context, linkIds ...uint) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clicks = append(b.clicks, linkIds...)
+	return nil
+}
+
+func (b *MemoryClickBuffer) Pop(ctx context.Context, max int) (string, []uint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := min(max, len(b.clicks))
+	popped := make([]uint, n)
+	copy(popped, b.clicks)
+	b.clicks = b.clicks[n:]
+	return "", popped, nil
+}
+
+func (b *MemoryClickBuffer) Ack(ctx context.Context, batch string, retry ...uint) error {
+	return b.Push(ctx, retry...)
+}
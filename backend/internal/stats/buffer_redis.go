@@ -0,0 +1,174 @@
+package stats
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Ключи в одном hash slot ({clicks}), чтобы MULTI работал и в Redis Cluster
+const (
+	redisClicksKey      = "linkshortener:{clicks}"
+	redisClickClaimsKey = "linkshortener:{clicks}:claims"
+	redisClickBatchKey  = "linkshortener:{clicks}:batch:"
+	// Пачка, не подтверждённая за это время, считается брошенной (инстанс упал) и возвращается в очередь
+	redisClickClaimTimeout = 5 * time.Minute
+	redisMaxRetries        = 10
+)
+
+var errClickBufferContention = errors.New("click batch is too contended")
+
+// Очередь кликов в списке Redis, общая для всех инстансов: клики переживают перезапуск инстанса
+// и записываются тем, кто первым заберёт их из списка. Pop переносит клики в отдельный список пачки
+// и отмечает время в claims; Ack удаляет пачку. Если инстанс упал между Pop и Ack, пачку вернёт
+// в очередь следующий Pop по истечении redisClickClaimTimeout — клики, успевшие попасть в БД,
+// тогда будут посчитаны повторно, но не потеряются
+type RedisClickBuffer struct {
+	client redis.UniversalClient
+}
+
+func NewRedisClickBuffer(client redis.UniversalClient) *RedisClickBuffer {
+	return &RedisClickBuffer{client: client}
+}
+
+func (b *RedisClickBuffer) Push(ctx context.Context, linkIds ...uint) error {
+	if len(linkIds) == 0 {
+		return nil
+	}
+	return b.client.RPush(ctx, redisClicksKey, values(linkIds)...).Err()
+}
+
+func (b *RedisClickBuffer) Pop(ctx context.Context, max int) (string, []uint, error) {
+	if err := b.recover(ctx); err != nil {
+		return "", nil, err
+	}
+
+	// Пачка регистрируется до переноса кликов, чтобы её можно было вернуть, даже если инстанс упадёт сразу после
+	batch := rand.Text()
+	if err := b.client.ZAdd(ctx, redisClickClaimsKey, redis.Z{Score: float64(time.Now().Unix()), Member: batch}).Err(); err != nil {
+		return "", nil, err
+	}
+
+	cmds, err := b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for range max {
+			pipe.LMove(ctx, redisClicksKey, redisClickBatchKey+batch, "LEFT", "RIGHT")
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return batch, nil, err
+	}
+
+	// Очередь могла опустеть и пополниться посреди конвейера, поэтому просматриваются все ответы
+	linkIds := make([]uint, 0, len(cmds))
+	for _, cmd := range cmds {
+		value, err := cmd.(*redis.StringCmd).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return batch, linkIds, err
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return batch, linkIds, err
+		}
+		linkIds = append(linkIds, uint(id))
+	}
+
+	if len(linkIds) == 0 {
+		return "", nil, b.Ack(ctx, batch)
+	}
+	return batch, linkIds, nil
+}
+
+// Возвращает retry в очередь и удаляет пачку одной транзакцией. Если пачку уже вернули
+// в очередь как брошенную, её клики, включая retry, уже там
+func (b *RedisClickBuffer) Ack(ctx context.Context, batch string, retry ...uint) error {
+	if batch == "" {
+		return nil
+	}
+	return b.settle(ctx, batch, func(tx *redis.Tx, batchKey string) ([]any, error) {
+		return values(retry), nil
+	})
+}
+
+// Возвращает в очередь клики пачек, которые не подтвердили за redisClickClaimTimeout
+func (b *RedisClickBuffer) recover(ctx context.Context) error {
+	stale, err := b.client.ZRangeByScore(ctx, redisClickClaimsKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Add(-redisClickClaimTimeout).Unix(), 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, batch := range stale {
+		err := b.settle(ctx, batch, func(tx *redis.Tx, batchKey string) ([]any, error) {
+			clicks, err := tx.LRange(ctx, batchKey, 0, -1).Result()
+			if err != nil {
+				return nil, err
+			}
+			requeued := make([]any, len(clicks))
+			for i, click := range clicks {
+				requeued[i] = click
+			}
+			return requeued, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Завершает пачку, если она ещё числится в claims. Ack и recover могут гоняться за одну пачку,
+// поэтому список пачки наблюдается через WATCH и изменения выполняются только один раз
+func (b *RedisClickBuffer) settle(ctx context.Context, batch string, requeue func(tx *redis.Tx, batchKey string) ([]any, error)) error {
+	batchKey := redisClickBatchKey + batch
+
+	settleBatch := func(tx *redis.Tx) error {
+		err := tx.ZScore(ctx, redisClickClaimsKey, batch).Err()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		clicks, err := requeue(tx, batchKey)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if len(clicks) > 0 {
+				pipe.RPush(ctx, redisClicksKey, clicks...)
+			}
+			pipe.Del(ctx, batchKey)
+			pipe.ZRem(ctx, redisClickClaimsKey, batch)
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < redisMaxRetries; attempt++ {
+		err := b.client.Watch(ctx, settleBatch, batchKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		return err
+	}
+	return errClickBufferContention
+}
+
+func values(linkIds []uint) []any {
+	values := make([]any, len(linkIds))
+	for i, id := range linkIds {
+		values[i] = uint64(id)
+	}
+	return values
+}
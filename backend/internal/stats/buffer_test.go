@@ -0,0 +1,69 @@
+package stats_test
+
+import (
+	"linkshortener/internal/stats"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisClickBuffer(t *testing.T) (*stats.RedisClickBuffer, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return stats.NewRedisClickBuffer(client), server
+}
+
+func TestRedisClickBufferKeepsClicksUntilAck(t *testing.T) {
+	buffer, server := newRedisClickBuffer(t)
+	if err := buffer.Push(t.Context(), 1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, linkIds, err := buffer.Pop(t.Context(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(linkIds, []uint{1, 2}) {
+		t.Fatalf("expected first two clicks, got %v", linkIds)
+	}
+	if !server.Exists("linkshortener:{clicks}:batch:" + batch) {
+		t.Fatal("expected popped clicks to stay in the batch until ack")
+	}
+
+	// Клик 2 не записался и возвращается в очередь, клик 1 подтверждён
+	if err := buffer.Ack(t.Context(), batch, 2); err != nil {
+		t.Fatal(err)
+	}
+	_, linkIds, err = buffer.Pop(t.Context(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(linkIds, []uint{3, 2}) {
+		t.Fatalf("expected remaining and retried clicks, got %v", linkIds)
+	}
+}
+
+// Пачку инстанса, упавшего до Ack, забирает следующий Pop
+func TestRedisClickBufferRecoversAbandonedBatches(t *testing.T) {
+	buffer, server := newRedisClickBuffer(t)
+	server.RPush("linkshortener:{clicks}:batch:dead", "4", "5")
+	server.ZAdd("linkshortener:{clicks}:claims", 0, "dead")
+
+	batch, linkIds, err := buffer.Pop(t.Context(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(linkIds, []uint{4, 5}) {
+		t.Fatalf("expected clicks of abandoned batch, got %v", linkIds)
+	}
+	if err := buffer.Ack(t.Context(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("linkshortener:{clicks}") || server.Exists("linkshortener:{clicks}:claims") {
+		t.Fatal("expected click buffer to be empty")
+	}
+}
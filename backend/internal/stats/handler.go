@@ -2,6 +2,8 @@ package stats
 
 import (
 	"linkshortener/config"
+	"linkshortener/pkg/di"
+	"linkshortener/pkg/middleware"
 	"linkshortener/pkg/res"
 	"net/http"
 	"time"
@@ -9,7 +11,9 @@ import (
 
 type StatsHandlerDeps struct {
 	Config          *config.Config
-	StatsRepository *StatsRepository
+	StatsRepository di.IStatsRepository[StatsResponse]
+	UserRepository  di.IUserRepository
+	UserLinks       di.IUserLinks
 }
 
 type StatsHandler struct {
@@ -20,36 +24,85 @@ func NewStatsHandler(router *http.ServeMux, deps *StatsHandlerDeps) {
 	statsHandler := &StatsHandler{
 		deps: deps,
 	}
-	router.HandleFunc("GET /stats", statsHandler.GetStats())
+	router.Handle("GET /stats", middleware.IsAuthenticated(statsHandler.GetStats(), deps.Config, deps.UserRepository))
+	// Статистика всего инстанса, включая ссылки без автора, доступна только администраторам
+	router.Handle("GET /admin/stats", middleware.IsAdmin(statsHandler.GetAllStats(), deps.Config, deps.UserRepository))
 }
 
+// Клики по ссылкам текущего пользователя
 func (handler *StatsHandler) GetStats() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
-		if err != nil {
-			http.Error(w, "from and to are required", http.StatusBadRequest)
+		query, ok := parseStatsQuery(w, r)
+		if !ok {
+			return
+		}
+		currentUser, ok := middleware.CurrentUser(r.Context())
+		if !ok {
+			res.Error(w, r, http.StatusUnauthorized, res.CodeUnauthorized)
 			return
 		}
 
-		endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+		linkIds, err := handler.deps.UserLinks.GetUserLinkIds(r.Context(), currentUser.ID)
 		if err != nil {
-			http.Error(w, "invalid end date", http.StatusBadRequest)
+			res.InternalError(w, r, err)
 			return
 		}
-
-		if startDate.After(endDate) {
-			http.Error(w, "start date must be before end date", http.StatusBadRequest)
+		stats, err := handler.deps.StatsRepository.GetLinksStats(r.Context(), linkIds, query.by, query.startDate, query.endDate)
+		if err != nil {
+			res.InternalError(w, r, err)
 			return
 		}
 
-		by := r.URL.Query().Get("by")
-		if by != "day" && by != "month" {
-			http.Error(w, "invalid by", http.StatusBadRequest)
+		res.Response(w, http.StatusOK, stats)
+	}
+}
+
+func (handler *StatsHandler) GetAllStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, ok := parseStatsQuery(w, r)
+		if !ok {
 			return
 		}
 
-		stats := handler.deps.StatsRepository.GetStats(by, startDate, endDate)
+		stats, err := handler.deps.StatsRepository.GetStats(r.Context(), query.by, query.startDate, query.endDate)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
 
 		res.Response(w, http.StatusOK, stats)
 	}
 }
+
+type statsQuery struct {
+	by                 string
+	startDate, endDate time.Time
+}
+
+// Разбирает from, to и by; при ошибке уже ответил клиенту
+func parseStatsQuery(w http.ResponseWriter, r *http.Request) (statsQuery, bool) {
+	startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		res.Error(w, r, http.StatusBadRequest, res.CodeInvalidDate)
+		return statsQuery{}, false
+	}
+
+	endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil {
+		res.Error(w, r, http.StatusBadRequest, res.CodeInvalidDate)
+		return statsQuery{}, false
+	}
+
+	if startDate.After(endDate) {
+		res.Error(w, r, http.StatusBadRequest, res.CodeInvalidDateRange)
+		return statsQuery{}, false
+	}
+
+	by := r.URL.Query().Get("by")
+	if by != "day" && by != "month" {
+		res.Error(w, r, http.StatusBadRequest, res.CodeInvalidGrouping)
+		return statsQuery{}, false
+	}
+
+	return statsQuery{by: by, startDate: startDate, endDate: endDate}, true
+}
@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Хранилище статистики в памяти процесса (драйвер БД memory)
+type MemoryStatsRepository struct {
+	mu     sync.RWMutex
+	stats  []Stats
+	nextID uint
+}
+
+func NewMemoryStatsRepository() *MemoryStatsRepository {
+	return &MemoryStatsRepository{}
+}
+
+func (repo *MemoryStatsRepository) AddClicks(ctx context.Context, linkId uint, count uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+	today := now.Format(time.DateOnly)
+	for i := range repo.stats {
+		if repo.stats[i].LinkId == linkId && time.Time(repo.stats[i].Date).Format(time.DateOnly) == today {
+			repo.stats[i].ClickCount += count
+			repo.stats[i].UpdatedAt = now
+			return nil
+		}
+	}
+
+	repo.nextID++
+	stats := Stats{
+		LinkId:     linkId,
+		ClickCount: count,
+		Date:       datatypes.Date(now),
+	}
+	stats.ID = repo.nextID
+	stats.CreatedAt = now
+	stats.UpdatedAt = now
+	repo.stats = append(repo.stats, stats)
+	return nil
+}
+
+func (repo *MemoryStatsRepository) GetStats(ctx context.Context, by string, startDate, endDate time.Time) (StatsResponse, error) {
+	return repo.collect(ctx, nil, by, startDate, endDate)
+}
+
+func (repo *MemoryStatsRepository) GetLinksStats(ctx context.Context, linkIds []uint, by string, startDate, endDate time.Time) (StatsResponse, error) {
+	selected := make(map[uint]bool, len(linkIds))
+	for _, id := range linkIds {
+		selected[id] = true
+	}
+	return repo.collect(ctx, selected, by, startDate, endDate)
+}
+
+// Клики за период по всем ссылкам (selected == nil) или только по selected
+func (repo *MemoryStatsRepository) collect(ctx context.Context, selected map[uint]bool, by string, startDate, endDate time.Time) (StatsResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return StatsResponse{}, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	from, to := startDate.Format(time.DateOnly), endDate.Format(time.DateOnly)
+	totalClicks := 0
+	periods := make(map[StatsPayload]int)
+	for _, stats := range repo.stats {
+		if selected != nil && !selected[stats.LinkId] {
+			continue
+		}
+		date := time.Time(stats.Date)
+		if day := date.Format(time.DateOnly); day < from || day > to {
+			continue
+		}
+		totalClicks += int(stats.ClickCount)
+		periods[period(by, date)] += int(stats.ClickCount)
+	}
+
+	response := StatsResponse{Stats: make([]StatsPayload, 0, len(periods)), TotalClicks: totalClicks}
+	for payload, clicks := range periods {
+		payload.Clicks = clicks
+		response.Stats = append(response.Stats, payload)
+	}
+	slices.SortFunc(response.Stats, func(a, b StatsPayload) int { return strings.Compare(a.PeriodFrom, b.PeriodFrom) })
+	return response, nil
+}
+
+// Границы дня или месяца, в который попадает дата, как их группирует SQL-хранилище
+func period(by string, date time.Time) StatsPayload {
+	if by == StatsByMonth {
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+		return StatsPayload{PeriodFrom: start.Format(time.DateOnly), PeriodTo: start.AddDate(0, 1, -1).Format(time.DateOnly)}
+	}
+	return StatsPayload{PeriodFrom: date.Format(time.DateOnly), PeriodTo: date.Format(time.DateOnly)}
+}
@@ -7,7 +7,7 @@ import (
 
 type Stats struct {
 	gorm.Model
-	LinkId     uint           `json:"link_id"`
+	LinkId     uint           `gorm:"uniqueIndex:idx_stats_link_date,priority:1" json:"link_id"`
 	ClickCount uint           `json:"click_count"`
-	Date       datatypes.Date `json:"date"`
+	Date       datatypes.Date `gorm:"uniqueIndex:idx_stats_link_date,priority:2" json:"date"`
 }
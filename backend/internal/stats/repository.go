@@ -1,10 +1,13 @@
 package stats
 
 import (
+	"context"
 	"linkshortener/pkg/db"
 	"time"
 
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -20,58 +23,130 @@ func NewStatsRepository(db *db.Db) *StatsRepository {
 	return &StatsRepository{db: db}
 }
 
-func (repo *StatsRepository) AddClick(linkId uint) error {
-	var stats Stats
-	currentDate := datatypes.Date(time.Now())
-	repo.db.DB.Find(&stats, "link_id = ? AND date = ?", linkId, currentDate)
-	if stats.ID == 0 {
-		repo.db.DB.Create(&Stats{
-			LinkId:     linkId,
-			ClickCount: 1,
-			Date:       currentDate,
-		})
-	} else {
-		stats.ClickCount++
-		repo.db.DB.Save(&stats)
-	}
+// Прибавляет клики к строке за сегодня одним INSERT ... ON CONFLICT на primary: раздельные чтение
+// и вставка с разных инстансов или через отстающую реплику создавали бы дубли строк за день
+func (repo *StatsRepository) AddClicks(ctx context.Context, linkId uint, count uint) error {
+	tx, cancel := repo.db.Query(db.WithPrimary(ctx))
+	defer cancel()
+
+	now := time.Now()
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "link_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"click_count": gorm.Expr("stats.click_count + excluded.click_count"),
+			"updated_at":  now,
+		}),
+	}).Create(&Stats{
+		LinkId:     linkId,
+		ClickCount: count,
+		Date:       datatypes.Date(now),
+	}).Error
+}
+
+func (repo *StatsRepository) GetStats(ctx context.Context, by string, startDate, endDate time.Time) (StatsResponse, error) {
+	return repo.collect(ctx, nil, by, startDate, endDate)
+}
 
-	return nil
+func (repo *StatsRepository) GetLinksStats(ctx context.Context, linkIds []uint, by string, startDate, endDate time.Time) (StatsResponse, error) {
+	if linkIds == nil {
+		linkIds = []uint{}
+	}
+	return repo.collect(ctx, linkIds, by, startDate, endDate)
 }
 
-func (repo *StatsRepository) GetStats(by string, startDate, endDate time.Time) StatsResponse {
-	var stats []StatsPayload
+// Клики за период по всем ссылкам (linkIds == nil) или только по linkIds
+func (repo *StatsRepository) collect(ctx context.Context, linkIds []uint, by string, startDate, endDate time.Time) (StatsResponse, error) {
+	tx, cancel := repo.db.Report(ctx)
+	defer cancel()
+
+	stats := []StatsPayload{}
 	var totalClicks int
+	dialect := repo.dialect()
+	dateRange, from, to := dialect.dateRange(startDate, endDate)
 
-	repo.db.DB.Table("stats").
-		Select("sum(click_count)").
-		Where("date BETWEEN ? AND ?", startDate, endDate).
-		Scan(&totalClicks)
+	err := tx.Table("stats").
+		Select("COALESCE(sum(click_count), 0)").
+		Where(dateRange, from, to).
+		Scopes(links(linkIds)).
+		Scan(&totalClicks).Error
+	if err != nil {
+		return StatsResponse{}, err
+	}
 
 	switch by {
 	case StatsByDay:
-		repo.db.DB.Table("stats").
-			Select("to_char(date, 'YYYY-MM-DD') as period_from, to_char(date, 'YYYY-MM-DD') as period_to, sum(click_count) as clicks").
-			Where("date BETWEEN ? AND ?", startDate, endDate).
-			Group("to_char(date, 'YYYY-MM-DD')").
-			Order("to_char(date, 'YYYY-MM-DD')").
-			Scan(&stats)
+		err = tx.Table("stats").
+			Select(dialect.day+" as period_from, "+dialect.day+" as period_to, sum(click_count) as clicks").
+			Where(dateRange, from, to).
+			Scopes(links(linkIds)).
+			Group(dialect.day).
+			Order(dialect.day).
+			Scan(&stats).Error
 	case StatsByMonth:
-		repo.db.DB.Table("stats").
-			Select("to_char(date_trunc('month', date), 'YYYY-MM-DD') as period_from, to_char(date_trunc('month', date) + interval '1 month' - interval '1 day', 'YYYY-MM-DD') as period_to, sum(click_count) as clicks").
-			Where("date BETWEEN ? AND ?", startDate, endDate).
-			Group("date_trunc('month', date)").
-			Order("date_trunc('month', date)").
-			Scan(&stats)
+		err = tx.Table("stats").
+			Select(dialect.monthFrom+" as period_from, "+dialect.monthTo+" as period_to, sum(click_count) as clicks").
+			Where(dateRange, from, to).
+			Scopes(links(linkIds)).
+			Group(dialect.month).
+			Order(dialect.month).
+			Scan(&stats).Error
+	}
+	if err != nil {
+		return StatsResponse{}, err
 	}
 
 	return StatsResponse{
-		Stats: []StatsPayload{
-			{
-				PeriodFrom: startDate.Format("2006-01-02"),
-				PeriodTo:   endDate.Format("2006-01-02"),
-				Clicks:     totalClicks,
-			},
-		},
+		Stats:       stats,
 		TotalClicks: totalClicks,
+	}, nil
+}
+
+func links(linkIds []uint) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if linkIds == nil {
+			return tx
+		}
+		if len(linkIds) == 0 {
+			return tx.Where("1 = 0")
+		}
+		return tx.Where("link_id IN ?", linkIds)
+	}
+}
+
+// SQL-выражения для группировки кликов по периодам в конкретной СУБД
+type statsDialect struct {
+	day       string
+	month     string
+	monthFrom string
+	monthTo   string
+	dateRange func(startDate, endDate time.Time) (string, any, any)
+}
+
+var postgresStatsDialect = statsDialect{
+	day:       "to_char(date, 'YYYY-MM-DD')",
+	month:     "date_trunc('month', date)",
+	monthFrom: "to_char(date_trunc('month', date), 'YYYY-MM-DD')",
+	monthTo:   "to_char(date_trunc('month', date) + interval '1 month' - interval '1 day', 'YYYY-MM-DD')",
+	dateRange: func(startDate, endDate time.Time) (string, any, any) {
+		return "date BETWEEN ? AND ?", startDate, endDate
+	},
+}
+
+// В SQLite дата хранится строкой с часовым поясом ("2024-01-31T00:00:00+03:00"), поэтому
+// день берётся из префикса строки: функции дат SQLite перевели бы её в UTC и сдвинули день
+var sqliteStatsDialect = statsDialect{
+	day:       "substr(date, 1, 10)",
+	month:     "substr(date, 1, 7)",
+	monthFrom: "substr(date, 1, 7) || '-01'",
+	monthTo:   "date(substr(date, 1, 7) || '-01', '+1 month', '-1 day')",
+	dateRange: func(startDate, endDate time.Time) (string, any, any) {
+		return "substr(date, 1, 10) BETWEEN ? AND ?", startDate.Format("2006-01-02"), endDate.Format("2006-01-02")
+	},
+}
+
+func (repo *StatsRepository) dialect() statsDialect {
+	if repo.db.IsSQLite() {
+		return sqliteStatsDialect
 	}
+	return postgresStatsDialect
 }
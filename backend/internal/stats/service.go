@@ -1,33 +1,187 @@
 package stats
 
 import (
+	"context"
+	"errors"
 	"linkshortener/pkg/di"
 	"linkshortener/pkg/event"
-	"log"
+	"linkshortener/pkg/metrics"
+	"linkshortener/pkg/tracing"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
+const maxFlushBatch = 512
+
+type click struct {
+	linkId uint
+	link   trace.Link
+}
+
 type StatsServiceDeps struct {
 	EventBus        di.IEventBus
-	StatsRepository di.IStatsRepository
+	StatsRepository di.IStatsRepository[StatsResponse]
+	// Очередь, через которую клики попадают в БД. Если не задана — в памяти процесса
+	ClickBuffer di.IClickBuffer
 }
 
 type StatsService struct {
-	deps *StatsServiceDeps
+	deps    *StatsServiceDeps
+	running atomic.Bool
+	done    chan struct{}
 }
 
 func NewStatsService(deps *StatsServiceDeps) *StatsService {
-	return &StatsService{deps: deps}
+	if deps.ClickBuffer == nil {
+		deps.ClickBuffer = NewMemoryClickBuffer()
+	}
+	return &StatsService{
+		deps: deps,
+		done: make(chan struct{}),
+	}
 }
 
+// Получив событие, забирает из очереди все уже накопившиеся клики и записывает их одной пачкой
 func (s *StatsService) AddClick() {
-	for msg := range s.deps.EventBus.Subscribe() {
-		if msg.Type == event.LinkClicked {
-			id, ok := msg.Data.(uint)
-			if !ok {
-				log.Fatalln("Bad LinkClicked Data: ", msg.Data)
-				continue
+	s.running.Store(true)
+	defer close(s.done)
+	defer s.running.Store(false)
+
+	// Клики, оставшиеся в общей очереди после перезапуска, дописываются сразу, не дожидаясь нового клика
+	s.flush(nil)
+
+	events := s.deps.EventBus.Subscribe()
+	for msg := range events {
+		batch := s.appendClick(nil, msg)
+
+	drain:
+		for len(batch) < maxFlushBatch {
+			select {
+			case next, ok := <-events:
+				if !ok {
+					break drain
+				}
+				batch = s.appendClick(batch, next)
+			default:
+				break drain
 			}
-			s.deps.StatsRepository.AddClick(id)
+		}
+
+		s.flush(batch)
+	}
+}
+
+func (s *StatsService) Running() bool {
+	return s.running.Load()
+}
+
+// Ждёт, пока обработчик запишет оставшиеся клики после закрытия шины событий
+func (s *StatsService) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *StatsService) appendClick(batch []click, msg event.Event) []click {
+	if msg.Type != event.LinkClicked {
+		return batch
+	}
+	id, ok := msg.Data.(uint)
+	if !ok {
+		slog.Error("bad LinkClicked data", "data", msg.Data)
+		return batch
+	}
+	return append(batch, click{
+		linkId: id,
+		link:   trace.LinkFromContext(msg.Context(context.Background())),
+	})
+}
+
+// Спан пачки связан со спанами публикации всех входящих в неё событий.
+// Клики сначала кладутся в очередь и записываются уже из неё, поэтому в Redis они переживают
+// перезапуск инстанса; вместе с ними дописываются клики, отложенные раньше, в том числе другими инстансами
+func (s *StatsService) flush(batch []click) {
+	links := make([]trace.Link, 0, len(batch))
+	linkIds := make([]uint, 0, len(batch))
+	for _, c := range batch {
+		links = append(links, c.link)
+		linkIds = append(linkIds, c.linkId)
+	}
+	ctx, span := tracing.Tracer().Start(context.Background(), "stats.flush_clicks",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(batch))),
+	)
+	defer span.End()
+
+	if err := s.deps.ClickBuffer.Push(ctx, linkIds...); err != nil {
+		span.RecordError(err)
+		slog.Error("failed to buffer clicks, writing them directly", "clicks", len(linkIds), "error", err)
+		if retry := s.write(ctx, span, linkIds); len(retry) > 0 {
+			slog.Error("failed to write unbuffered clicks, clicks are lost", "clicks", len(retry))
+		}
+		return
+	}
+	s.drain(ctx, span)
+}
+
+// Пачка из очереди подтверждается только после записи; клики, которые записать не удалось, возвращаются в очередь,
+// а вычитывание прекращается до следующей пачки: БД, скорее всего, недоступна
+func (s *StatsService) drain(ctx context.Context, span trace.Span) {
+	for {
+		batch, linkIds, err := s.deps.ClickBuffer.Pop(ctx, maxFlushBatch)
+		if err != nil {
+			span.RecordError(err)
+			slog.Error("failed to read buffered clicks", "error", err)
+			return
+		}
+		if len(linkIds) == 0 {
+			return
+		}
+
+		retry := s.write(ctx, span, linkIds)
+		if err := s.deps.ClickBuffer.Ack(ctx, batch, retry...); err != nil {
+			span.RecordError(err)
+			slog.Error("failed to acknowledge buffered clicks", "error", err)
+			return
+		}
+		if len(retry) > 0 || len(linkIds) < maxFlushBatch {
+			return
+		}
+	}
+}
+
+// Клики суммируются по ссылкам и записываются одним обновлением на ссылку.
+// Возвращает клики, запись которых стоит повторить: клики удалённой ссылки не повторяются
+func (s *StatsService) write(ctx context.Context, span trace.Span, linkIds []uint) []uint {
+	startTime := time.Now()
+	counts := make(map[uint]uint)
+	for _, linkId := range linkIds {
+		counts[linkId]++
+	}
+
+	var retry []uint
+	for linkId, count := range counts {
+		err := s.deps.StatsRepository.AddClicks(ctx, linkId, count)
+		if err == nil {
+			continue
+		}
+		span.RecordError(err)
+		slog.Error("failed to add clicks", "link_id", linkId, "clicks", count, "error", err)
+		if !errors.Is(err, gorm.ErrForeignKeyViolated) {
+			retry = append(retry, slices.Repeat([]uint{linkId}, int(count))...)
 		}
 	}
+	metrics.ClickFlushBatchSize.Observe(float64(len(linkIds)))
+	metrics.ClickFlushDuration.Observe(time.Since(startTime).Seconds())
+	return retry
 }
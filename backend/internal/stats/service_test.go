@@ -1,12 +1,17 @@
 package stats_test
 
 import (
+	"context"
+	"errors"
 	"linkshortener/internal/stats"
+	"linkshortener/pkg/di"
 	"linkshortener/pkg/event"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/datatypes"
 )
 
@@ -28,20 +33,30 @@ func (m *MockEventBus) Subscribe() <-chan event.Event {
 	return m.channel
 }
 
+// StatsService вызывает только AddClicks. clicks — по элементу на каждый записанный клик;
+// пока failures > 0, запись отказывает
 type MockStatsRepository struct {
-	addClickCalls []uint
-	stats         map[uint][]stats.Stats
+	di.IStatsRepository[stats.StatsResponse]
+	clicks   []uint
+	stats    map[uint][]stats.Stats
+	failures int
 }
 
 func NewMockStatsRepository() *MockStatsRepository {
 	return &MockStatsRepository{
-		addClickCalls: make([]uint, 0),
-		stats:         make(map[uint][]stats.Stats),
+		clicks: make([]uint, 0),
+		stats:  make(map[uint][]stats.Stats),
 	}
 }
 
-func (m *MockStatsRepository) AddClick(linkId uint) error {
-	m.addClickCalls = append(m.addClickCalls, linkId)
+func (m *MockStatsRepository) AddClicks(ctx context.Context, linkId uint, count uint) error {
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("database is unavailable")
+	}
+	for range count {
+		m.clicks = append(m.clicks, linkId)
+	}
 
 	today := time.Now()
 	currentDate := datatypes.Date(today)
@@ -51,7 +66,7 @@ func (m *MockStatsRepository) AddClick(linkId uint) error {
 		statDay := time.Time(stat.Date).Format("2006-01-02")
 		currentDay := today.Format("2006-01-02")
 		if statDay == currentDay {
-			m.stats[linkId][i].ClickCount++
+			m.stats[linkId][i].ClickCount += count
 			found = true
 			break
 		}
@@ -63,7 +78,7 @@ func (m *MockStatsRepository) AddClick(linkId uint) error {
 		}
 		m.stats[linkId] = append(m.stats[linkId], stats.Stats{
 			LinkId:     linkId,
-			ClickCount: 1,
+			ClickCount: count,
 			Date:       currentDate,
 		})
 	}
@@ -101,12 +116,12 @@ func TestStatsServiceAddClick(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	if len(mockStatsRepo.addClickCalls) != 1 {
-		t.Fatalf("Expected 1 AddClick call, got %d", len(mockStatsRepo.addClickCalls))
+	if len(mockStatsRepo.clicks) != 1 {
+		t.Fatalf("Expected 1 click, got %d", len(mockStatsRepo.clicks))
 	}
 
-	if mockStatsRepo.addClickCalls[0] != linkId {
-		t.Fatalf("Expected linkId %d, got %d", linkId, mockStatsRepo.addClickCalls[0])
+	if mockStatsRepo.clicks[0] != linkId {
+		t.Fatalf("Expected linkId %d, got %d", linkId, mockStatsRepo.clicks[0])
 	}
 }
 
@@ -130,8 +145,8 @@ func TestStatsServiceMultipleClicks(t *testing.T) {
 
 	time.Sleep(200 * time.Millisecond)
 
-	if len(mockStatsRepo.addClickCalls) != 3 {
-		t.Fatalf("Expected 3 AddClick calls, got %d", len(mockStatsRepo.addClickCalls))
+	if len(mockStatsRepo.clicks) != 3 {
+		t.Fatalf("Expected 3 clicks, got %d", len(mockStatsRepo.clicks))
 	}
 
 	if len(mockStatsRepo.stats[123]) != 1 {
@@ -172,11 +187,151 @@ func TestStatsServiceIgnoreNonLinkClickedEvents(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	if len(mockStatsRepo.addClickCalls) != 1 {
-		t.Fatalf("Expected 1 AddClick call, got %d", len(mockStatsRepo.addClickCalls))
+	if len(mockStatsRepo.clicks) != 1 {
+		t.Fatalf("Expected 1 click, got %d", len(mockStatsRepo.clicks))
+	}
+
+	if mockStatsRepo.clicks[0] != 456 {
+		t.Fatalf("Expected linkId 456, got %d", mockStatsRepo.clicks[0])
+	}
+}
+
+func TestStatsServiceFlushesQueuedClicksOnClose(t *testing.T) {
+	statsService, mockEventBus, mockStatsRepo := setupStatsService()
+
+	for _, linkId := range []uint{1, 2, 3} {
+		mockEventBus.Publish(event.Event{
+			Type: event.LinkClicked,
+			Data: linkId,
+		})
+	}
+	close(mockEventBus.channel)
+
+	go statsService.AddClick()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := statsService.Wait(ctx); err != nil {
+		t.Fatalf("Expected consumer to stop, got %v", err)
+	}
+
+	if len(mockStatsRepo.clicks) != 3 {
+		t.Fatalf("Expected 3 clicks, got %d", len(mockStatsRepo.clicks))
+	}
+}
+
+// Клики, которые один инстанс положил в общий буфер Redis, может записать другой
+func TestStatsServiceRedisClickBuffer(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	buffer := stats.NewRedisClickBuffer(client)
+	if err := buffer.Push(t.Context(), 7, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	mockEventBus := NewMockEventBus()
+	mockStatsRepo := NewMockStatsRepository()
+	statsService := stats.NewStatsService(&stats.StatsServiceDeps{
+		EventBus:        mockEventBus,
+		StatsRepository: mockStatsRepo,
+		ClickBuffer:     buffer,
+	})
+
+	mockEventBus.Publish(event.Event{
+		Type: event.LinkClicked,
+		Data: uint(9),
+	})
+	close(mockEventBus.channel)
+
+	go statsService.AddClick()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := statsService.Wait(ctx); err != nil {
+		t.Fatalf("Expected consumer to stop, got %v", err)
+	}
+
+	if len(mockStatsRepo.clicks) != 3 {
+		t.Fatalf("Expected 3 clicks, got %v", mockStatsRepo.clicks)
+	}
+	if server.Exists("linkshortener:{clicks}") || server.Exists("linkshortener:{clicks}:claims") {
+		t.Fatal("Expected click buffer to be drained")
+	}
+}
+
+// Клики попадают в Redis до записи в БД: если инстанс не смог их записать и остановился,
+// их запишет следующий сразу после запуска
+func TestStatsServiceRedisClicksSurviveRestart(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	run := func(repo *MockStatsRepository, linkIds ...uint) {
+		t.Helper()
+		mockEventBus := NewMockEventBus()
+		statsService := stats.NewStatsService(&stats.StatsServiceDeps{
+			EventBus:        mockEventBus,
+			StatsRepository: repo,
+			ClickBuffer:     stats.NewRedisClickBuffer(client),
+		})
+		for _, linkId := range linkIds {
+			mockEventBus.Publish(event.Event{Type: event.LinkClicked, Data: linkId})
+		}
+		close(mockEventBus.channel)
+
+		go statsService.AddClick()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := statsService.Wait(ctx); err != nil {
+			t.Fatalf("Expected consumer to stop, got %v", err)
+		}
+	}
+
+	unavailable := NewMockStatsRepository()
+	unavailable.failures = 100
+	run(unavailable, 1, 2, 1)
+	if queued, _ := server.List("linkshortener:{clicks}"); len(queued) != 3 {
+		t.Fatalf("Expected unwritten clicks to stay in Redis, got %v", queued)
+	}
+
+	restarted := NewMockStatsRepository()
+	run(restarted)
+	if len(restarted.clicks) != 3 || restarted.stats[1][0].ClickCount != 2 || restarted.stats[2][0].ClickCount != 1 {
+		t.Fatalf("Expected clicks from the stopped instance to be written, got %v", restarted.clicks)
+	}
+	if server.Exists("linkshortener:{clicks}") || server.Exists("linkshortener:{clicks}:claims") {
+		t.Fatal("Expected click buffer to be drained")
+	}
+}
+
+// Клик, который не удалось записать, не теряется и дописывается со следующей удачной пачкой
+func TestStatsServiceRetriesFailedClicks(t *testing.T) {
+	statsService, mockEventBus, mockStatsRepo := setupStatsService()
+	mockStatsRepo.failures = 1
+
+	go statsService.AddClick()
+
+	mockEventBus.Publish(event.Event{
+		Type: event.LinkClicked,
+		Data: uint(5),
+	})
+	time.Sleep(100 * time.Millisecond)
+	mockEventBus.Publish(event.Event{
+		Type: event.LinkClicked,
+		Data: uint(6),
+	})
+	close(mockEventBus.channel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := statsService.Wait(ctx); err != nil {
+		t.Fatalf("Expected consumer to stop, got %v", err)
 	}
 
-	if mockStatsRepo.addClickCalls[0] != 456 {
-		t.Fatalf("Expected linkId 456, got %d", mockStatsRepo.addClickCalls[0])
+	if len(mockStatsRepo.clicks) != 2 || mockStatsRepo.stats[5] == nil || mockStatsRepo.stats[6] == nil {
+		t.Fatalf("Expected failed click to be retried, got %v", mockStatsRepo.clicks)
 	}
 }
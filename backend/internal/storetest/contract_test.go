@@ -0,0 +1,131 @@
+package storetest_test
+
+import (
+	"context"
+	"linkshortener/internal/link"
+	"linkshortener/internal/stats"
+	"linkshortener/internal/storetest"
+	"linkshortener/internal/user"
+	"linkshortener/pkg/di"
+	"testing"
+)
+
+// Маленький пул, чтобы тесты проходили через его пополнение
+const codePoolSize = 4
+
+func newCodes() link.CodeGenerator {
+	return link.NewRandomGenerator(link.Base62Alphabet, link.DefaultHashLength)
+}
+
+func TestLinkRepositoryContract(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		storetest.LinkRepository(t, func(t *testing.T) di.ILinkRepository[link.Link] {
+			return link.NewMemoryLinkRepository(newCodes())
+		})
+	})
+	for _, database := range storetest.Databases() {
+		t.Run(database.Name, func(t *testing.T) {
+			storetest.LinkRepository(t, func(t *testing.T) di.ILinkRepository[link.Link] {
+				repo := link.NewLinkRepository(database.New(t), newCodes(), codePoolSize)
+				t.Cleanup(repo.Close)
+				return repo
+			})
+		})
+	}
+}
+
+// Выдаёт коды по списку, чтобы вызвать коллизию при вставке
+type scriptedCodes struct {
+	link.CodeGenerator
+	codes []string
+}
+
+func (s *scriptedCodes) Generate(ctx context.Context) (string, error) {
+	code := s.codes[0]
+	s.codes = s.codes[1:]
+	return code, nil
+}
+
+func TestLinkRepositoryRetriesOnHashCollision(t *testing.T) {
+	for _, database := range storetest.Databases() {
+		t.Run(database.Name, func(t *testing.T) {
+			codes := &scriptedCodes{codes: []string{"collision", "collision", "unique"}}
+			repo := link.NewLinkRepository(database.New(t), codes, 0)
+
+			first, err := repo.Create(t.Context(), link.NewLink("https://example.com/1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := repo.Create(t.Context(), link.NewLink("https://example.com/2"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first.Hash != "collision" || second.Hash != "unique" {
+				t.Fatalf("expected second link to get a new hash, got %q and %q", first.Hash, second.Hash)
+			}
+		})
+	}
+}
+
+// Два инстанса с общей БД получают непересекающиеся коды одного счётчика
+func TestCodeCounterIsSharedBetweenInstances(t *testing.T) {
+	for _, database := range storetest.Databases() {
+		t.Run(database.Name, func(t *testing.T) {
+			counter := link.NewCodeCounterRepository(database.New(t))
+			first := link.NewSequentialGenerator(link.Base62Alphabet, 6, counter)
+			second := link.NewSequentialGenerator(link.Base62Alphabet, 6, counter)
+
+			seen := make(map[string]bool)
+			for range 150 {
+				for _, generator := range []link.CodeGenerator{first, second} {
+					code, err := generator.Generate(t.Context())
+					if err != nil {
+						t.Fatal(err)
+					}
+					if seen[code] {
+						t.Fatalf("code %q was issued twice", code)
+					}
+					seen[code] = true
+				}
+			}
+
+			// Каждый инстанс зарезервировал по два блока
+			if current, err := counter.Current(t.Context()); err != nil || current != 400 {
+				t.Fatalf("expected counter at 400, got %d %v", current, err)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		storetest.UserRepository(t, func(t *testing.T) di.IUserRepository {
+			return user.NewMemoryUserRepository()
+		})
+	})
+	for _, database := range storetest.Databases() {
+		t.Run(database.Name, func(t *testing.T) {
+			storetest.UserRepository(t, func(t *testing.T) di.IUserRepository {
+				return user.NewUserRepository(database.New(t))
+			})
+		})
+	}
+}
+
+func TestStatsRepositoryContract(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		storetest.StatsRepository(t, func(t *testing.T) (di.IStatsRepository[stats.StatsResponse], di.ILinkRepository[link.Link]) {
+			return stats.NewMemoryStatsRepository(), link.NewMemoryLinkRepository(newCodes())
+		})
+	})
+	for _, database := range storetest.Databases() {
+		t.Run(database.Name, func(t *testing.T) {
+			storetest.StatsRepository(t, func(t *testing.T) (di.IStatsRepository[stats.StatsResponse], di.ILinkRepository[link.Link]) {
+				db := database.New(t)
+				links := link.NewLinkRepository(db, newCodes(), codePoolSize)
+				t.Cleanup(links.Close)
+				return stats.NewStatsRepository(db), links
+			})
+		})
+	}
+}
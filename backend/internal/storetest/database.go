@@ -0,0 +1,69 @@
+// Общие контрактные тесты хранилищ: каждая реализация репозитория (Postgres, SQLite, memory)
+// обязана проходить один и тот же набор проверок
+package storetest
+
+import (
+	"context"
+	"linkshortener/config"
+	"linkshortener/migrations"
+	"linkshortener/pkg/db"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type Database struct {
+	Name string
+	// Открывает пустую базу с применёнными миграциями
+	New func(t *testing.T) *db.Db
+}
+
+// SQLite во временном файле доступен всегда. Postgres подключается, если задан TEST_DB_URL;
+// схема в этой базе пересоздаётся для каждого теста, поэтому база должна быть одноразовой
+func Databases() []Database {
+	databases := []Database{{Name: config.DBDriverSQLite, New: newSQLite}}
+	if url := os.Getenv("TEST_DB_URL"); url != "" {
+		databases = append(databases, Database{
+			Name: config.DBDriverPostgres,
+			New: func(t *testing.T) *db.Db {
+				return open(t, config.DBDriverPostgres, url, true)
+			},
+		})
+	}
+	return databases
+}
+
+func newSQLite(t *testing.T) *db.Db {
+	return open(t, config.DBDriverSQLite, filepath.Join(t.TempDir(), "test.db"), false)
+}
+
+func open(t *testing.T, driver, url string, reset bool) *db.Db {
+	t.Helper()
+	cfg := config.Default()
+	cfg.DB.Driver = driver
+	cfg.DB.URL = url
+
+	database, err := migrations.Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := migrations.NewMigrator(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reset {
+		if err := migrator.To(context.Background(), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
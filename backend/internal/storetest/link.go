@@ -0,0 +1,263 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"linkshortener/internal/link"
+	"linkshortener/pkg/di"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type LinkRepositoryFactory func(t *testing.T) di.ILinkRepository[link.Link]
+
+func LinkRepository(t *testing.T, newRepo LinkRepositoryFactory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.Create(t.Context(), link.NewLink("https://example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.ID == 0 || len(created.Hash) != link.DefaultHashLength {
+			t.Fatalf("expected ID and %d-char hash, got %d %q", link.DefaultHashLength, created.ID, created.Hash)
+		}
+
+		byHash, err := repo.GetByHash(t.Context(), created.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byId, err := repo.GetById(t.Context(), created.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if byHash.ID != created.ID || byId.OriginalURL != "https://example.com" {
+			t.Fatalf("unexpected links: %+v %+v", byHash, byId)
+		}
+		if err := repo.FindById(t.Context(), created.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetByHash(t.Context(), "missing"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("GetByHash: expected ErrRecordNotFound, got %v", err)
+		}
+		if _, err := repo.GetById(t.Context(), 999); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("GetById: expected ErrRecordNotFound, got %v", err)
+		}
+		if err := repo.FindById(t.Context(), 999); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("FindById: expected ErrRecordNotFound, got %v", err)
+		}
+		if err := repo.Delete(t.Context(), 999); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("Delete: expected ErrRecordNotFound, got %v", err)
+		}
+		if _, err := repo.SetDisabled(t.Context(), 999, true); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("SetDisabled: expected ErrRecordNotFound, got %v", err)
+		}
+		update := &link.Link{OriginalURL: "https://example.com"}
+		update.ID = 999
+		if _, err := repo.Update(t.Context(), update); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("Update: expected ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		first, _ := repo.Create(t.Context(), link.NewLink("https://example.com/1"))
+		second, _ := repo.Create(t.Context(), link.NewLink("https://example.com/2"))
+
+		update := &link.Link{OriginalURL: "https://example.com/updated"}
+		update.ID = first.ID
+		updated, err := repo.Update(t.Context(), update)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.OriginalURL != "https://example.com/updated" || updated.Hash != first.Hash {
+			t.Fatalf("expected full updated record, got %+v", updated)
+		}
+
+		update = &link.Link{Hash: "customhash"}
+		update.ID = first.ID
+		if _, err := repo.Update(t.Context(), update); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := repo.GetByHash(t.Context(), "customhash"); err != nil || found.ID != first.ID {
+			t.Fatalf("expected link by new hash, got %+v %v", found, err)
+		}
+
+		update = &link.Link{Hash: second.Hash}
+		update.ID = first.ID
+		if _, err := repo.Update(t.Context(), update); !errors.Is(err, gorm.ErrDuplicatedKey) {
+			t.Fatalf("expected ErrDuplicatedKey, got %v", err)
+		}
+	})
+
+	t.Run("SetDisabled", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.Create(t.Context(), link.NewLink("https://example.com"))
+
+		disabled, err := repo.SetDisabled(t.Context(), created.ID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !disabled.Disabled {
+			t.Fatal("expected returned link to be disabled")
+		}
+		if found, _ := repo.GetById(t.Context(), created.ID); !found.Disabled {
+			t.Fatal("expected stored link to be disabled")
+		}
+
+		enabled, err := repo.SetDisabled(t.Context(), created.ID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enabled.Disabled {
+			t.Fatal("expected link to be enabled")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.Create(t.Context(), link.NewLink("https://example.com"))
+
+		if err := repo.Delete(t.Context(), created.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.GetById(t.Context(), created.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("expected deleted link to be gone, got %v", err)
+		}
+		if _, err := repo.GetByHash(t.Context(), created.Hash); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("expected deleted link to be gone by hash, got %v", err)
+		}
+		if err := repo.Delete(t.Context(), created.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("expected second delete to fail, got %v", err)
+		}
+	})
+
+	t.Run("GetLinks", func(t *testing.T) {
+		repo := newRepo(t)
+		var ids []uint
+		for range 5 {
+			created, err := repo.Create(t.Context(), link.NewLink("https://example.com"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, created.ID)
+		}
+		if err := repo.Delete(t.Context(), ids[4]); err != nil {
+			t.Fatal(err)
+		}
+
+		count, err := repo.GetLinksCount(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 4 {
+			t.Fatalf("expected 4 links, got %d", count)
+		}
+
+		links, err := repo.GetLinks(t.Context(), 2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(links) != 2 || links[0].ID != ids[2] || links[1].ID != ids[1] {
+			t.Fatalf("expected links %d and %d newest first, got %+v", ids[2], ids[1], links)
+		}
+
+		links, err = repo.GetLinks(t.Context(), 10, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(links) != 0 {
+			t.Fatalf("expected empty page, got %d links", len(links))
+		}
+	})
+
+	t.Run("UniqueHashes", func(t *testing.T) {
+		repo := newRepo(t)
+		seen := make(map[string]uint)
+		for range 20 {
+			created, err := repo.Create(t.Context(), link.NewLink("https://example.com"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id, ok := seen[created.Hash]; ok {
+				t.Fatalf("hash %q given to links %d and %d", created.Hash, id, created.ID)
+			}
+			seen[created.Hash] = created.ID
+		}
+	})
+
+	t.Run("GetHashesCount", func(t *testing.T) {
+		repo := newRepo(t)
+		if count, err := repo.GetHashesCount(t.Context()); err != nil || count != 0 {
+			t.Fatalf("expected 0 for empty repository, got %d %v", count, err)
+		}
+
+		repo.Create(t.Context(), link.NewLink("https://example.com/1"))
+		second, _ := repo.Create(t.Context(), link.NewLink("https://example.com/2"))
+		if err := repo.Delete(t.Context(), second.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		count, err := repo.GetHashesCount(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Fatalf("expected deleted link hash to count, got %d", count)
+		}
+	})
+
+	t.Run("GetUserLinkIds", func(t *testing.T) {
+		repo := newRepo(t)
+		owner, other := uint(1), uint(2)
+		owned := func(url string, userId *uint) *link.Link {
+			newLink := link.NewLink(url)
+			newLink.UserID = userId
+			created, err := repo.Create(t.Context(), newLink)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return created
+		}
+		first := owned("https://example.com/1", &owner)
+		owned("https://example.com/2", &other)
+		owned("https://example.com/3", nil)
+		deleted := owned("https://example.com/4", &owner)
+		if err := repo.Delete(t.Context(), deleted.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		linkIds, err := repo.GetUserLinkIds(t.Context(), owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(linkIds, []uint{first.ID, deleted.ID}) {
+			t.Fatalf("expected owner links %v including deleted, got %v", []uint{first.ID, deleted.ID}, linkIds)
+		}
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		repo := newRepo(t)
+		created, err := repo.Create(t.Context(), link.NewLink("https://example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		if _, err := repo.GetByHash(ctx, created.Hash); !errors.Is(err, context.Canceled) {
+			t.Fatalf("GetByHash: expected context.Canceled, got %v", err)
+		}
+		if _, err := repo.Create(ctx, link.NewLink("https://example.org")); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Create: expected context.Canceled, got %v", err)
+		}
+	})
+}
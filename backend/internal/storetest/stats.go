@@ -0,0 +1,100 @@
+package storetest
+
+import (
+	"linkshortener/internal/link"
+	"linkshortener/internal/stats"
+	"linkshortener/pkg/di"
+	"testing"
+	"time"
+)
+
+// Статистика ссылается на ссылки, поэтому фабрика возвращает оба репозитория одного хранилища
+type StatsRepositoryFactory func(t *testing.T) (di.IStatsRepository[stats.StatsResponse], di.ILinkRepository[link.Link])
+
+func StatsRepository(t *testing.T, newRepo StatsRepositoryFactory) {
+	t.Run("AddClicksAndGetStats", func(t *testing.T) {
+		repo, links := newRepo(t)
+		first, _ := links.Create(t.Context(), link.NewLink("https://example.com/1"))
+		second, _ := links.Create(t.Context(), link.NewLink("https://example.com/2"))
+
+		// Повторная запись по той же ссылке за день прибавляется к существующей строке
+		for _, clicks := range []struct{ linkId, count uint }{{first.ID, 1}, {second.ID, 2}, {first.ID, 3}} {
+			if err := repo.AddClicks(t.Context(), clicks.linkId, clicks.count); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		now := time.Now()
+		response, err := repo.GetStats(t.Context(), stats.StatsByDay, now.AddDate(0, 0, -1), now.AddDate(0, 0, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.TotalClicks != 6 {
+			t.Fatalf("by day: expected 6 clicks, got %d", response.TotalClicks)
+		}
+		today := now.Format(time.DateOnly)
+		if len(response.Stats) != 1 || response.Stats[0] != (stats.StatsPayload{PeriodFrom: today, PeriodTo: today, Clicks: 6}) {
+			t.Fatalf("by day: expected one bucket for today, got %+v", response.Stats)
+		}
+
+		startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		response, err = repo.GetStats(t.Context(), stats.StatsByMonth, startOfMonth, startOfMonth.AddDate(0, 1, -1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.TotalClicks != 6 {
+			t.Fatalf("by month: expected 6 clicks, got %d", response.TotalClicks)
+		}
+		month := stats.StatsPayload{
+			PeriodFrom: startOfMonth.Format(time.DateOnly),
+			PeriodTo:   startOfMonth.AddDate(0, 1, -1).Format(time.DateOnly),
+			Clicks:     6,
+		}
+		if len(response.Stats) != 1 || response.Stats[0] != month {
+			t.Fatalf("by month: expected bucket %+v, got %+v", month, response.Stats)
+		}
+	})
+
+	t.Run("GetLinksStats", func(t *testing.T) {
+		repo, links := newRepo(t)
+		first, _ := links.Create(t.Context(), link.NewLink("https://example.com/1"))
+		second, _ := links.Create(t.Context(), link.NewLink("https://example.com/2"))
+		repo.AddClicks(t.Context(), first.ID, 1)
+		repo.AddClicks(t.Context(), second.ID, 2)
+
+		now := time.Now()
+		from, to := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+		response, err := repo.GetLinksStats(t.Context(), []uint{second.ID}, stats.StatsByDay, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		today := now.Format(time.DateOnly)
+		if response.TotalClicks != 2 || len(response.Stats) != 1 || response.Stats[0] != (stats.StatsPayload{PeriodFrom: today, PeriodTo: today, Clicks: 2}) {
+			t.Fatalf("expected only second link clicks, got %d in %+v", response.TotalClicks, response.Stats)
+		}
+
+		// Пользователь без ссылок не видит чужих кликов
+		response, err = repo.GetLinksStats(t.Context(), nil, stats.StatsByDay, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.TotalClicks != 0 || len(response.Stats) != 0 {
+			t.Fatalf("expected no clicks without links, got %d in %+v", response.TotalClicks, response.Stats)
+		}
+	})
+
+	t.Run("EmptyRange", func(t *testing.T) {
+		repo, links := newRepo(t)
+		created, _ := links.Create(t.Context(), link.NewLink("https://example.com"))
+		repo.AddClicks(t.Context(), created.ID, 2)
+
+		lastWeek := time.Now().AddDate(0, 0, -7)
+		response, err := repo.GetStats(t.Context(), stats.StatsByDay, lastWeek.AddDate(0, 0, -1), lastWeek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.TotalClicks != 0 || len(response.Stats) != 0 {
+			t.Fatalf("expected no clicks, got %d in %+v", response.TotalClicks, response.Stats)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"linkshortener/internal/user"
+	"linkshortener/pkg/di"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type UserRepositoryFactory func(t *testing.T) di.IUserRepository
+
+func UserRepository(t *testing.T, newRepo UserRepositoryFactory) {
+	t.Run("CreateAndFind", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.Create(t.Context(), user.NewUser("alice@example.com", "hash", "Alice"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.ID == 0 || created.Role != user.RoleUser {
+			t.Fatalf("unexpected user: %+v", created)
+		}
+
+		byEmail, err := repo.FindByEmail(t.Context(), "alice@example.com")
+		if err != nil || byEmail == nil || byEmail.ID != created.ID {
+			t.Fatalf("FindByEmail: got %+v %v", byEmail, err)
+		}
+		byId, err := repo.FindById(t.Context(), created.ID)
+		if err != nil || byId.Email != "alice@example.com" {
+			t.Fatalf("FindById: got %+v %v", byId, err)
+		}
+
+		if _, err := repo.Create(t.Context(), user.NewUser("alice@example.com", "hash", "Other")); !errors.Is(err, gorm.ErrDuplicatedKey) {
+			t.Fatalf("expected ErrDuplicatedKey, got %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if found, err := repo.FindByEmail(t.Context(), "missing@example.com"); found != nil || err != nil {
+			t.Fatalf("FindByEmail: expected (nil, nil), got %+v %v", found, err)
+		}
+		if _, err := repo.FindById(t.Context(), 999); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("FindById: expected ErrRecordNotFound, got %v", err)
+		}
+		if _, err := repo.SetDisabled(t.Context(), 999, true); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("SetDisabled: expected ErrRecordNotFound, got %v", err)
+		}
+		if _, err := repo.SetRole(t.Context(), 999, user.RoleAdmin); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("SetRole: expected ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		repo := newRepo(t)
+		alice, _ := repo.Create(t.Context(), user.NewUser("alice@example.com", "hash", "Alice"))
+		bob, _ := repo.Create(t.Context(), user.NewUser("bob@example.com", "hash", "Bob"))
+		carol, _ := repo.Create(t.Context(), user.NewUser("carol@test.org", "hash", "Carol Alison"))
+
+		users, err := repo.GetUsers(t.Context(), "ALI", 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 2 || users[0].ID != carol.ID || users[1].ID != alice.ID {
+			t.Fatalf("expected carol and alice newest first, got %+v", users)
+		}
+
+		count, err := repo.GetUsersCount(t.Context(), "example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Fatalf("expected 2 users, got %d", count)
+		}
+
+		users, err = repo.GetUsers(t.Context(), "", 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 1 || users[0].ID != bob.ID {
+			t.Fatalf("expected bob on the second page, got %+v", users)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.Create(t.Context(), user.NewUser("alice@example.com", "hash", "Alice"))
+
+		disabled, err := repo.SetDisabled(t.Context(), created.ID, true)
+		if err != nil || !disabled.Disabled {
+			t.Fatalf("SetDisabled: got %+v %v", disabled, err)
+		}
+		admin, err := repo.SetRole(t.Context(), created.ID, user.RoleAdmin)
+		if err != nil || admin.Role != user.RoleAdmin {
+			t.Fatalf("SetRole: got %+v %v", admin, err)
+		}
+
+		found, _ := repo.FindById(t.Context(), created.ID)
+		if !found.Disabled || !found.IsAdmin() {
+			t.Fatalf("expected changes to be stored, got %+v", found)
+		}
+	})
+
+	t.Run("PromoteAdmins", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.Create(t.Context(), user.NewUser("alice@example.com", "hash", "Alice"))
+		repo.Create(t.Context(), user.NewUser("bob@example.com", "hash", "Bob"))
+
+		if err := repo.PromoteAdmins(t.Context(), []string{"bob@example.com", "missing@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.PromoteAdmins(t.Context(), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		alice, _ := repo.FindByEmail(t.Context(), "alice@example.com")
+		bob, _ := repo.FindByEmail(t.Context(), "bob@example.com")
+		if alice.IsAdmin() || !bob.IsAdmin() {
+			t.Fatalf("expected only bob to be admin, got %s and %s", alice.Role, bob.Role)
+		}
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		if _, err := repo.FindByEmail(ctx, "alice@example.com"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("FindByEmail: expected context.Canceled, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package user
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Хранилище пользователей в памяти процесса (драйвер БД memory)
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]*User
+	nextID uint
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users: make(map[uint]*User),
+	}
+}
+
+func (repo *MemoryUserRepository) Create(ctx context.Context, user *User) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, stored := range repo.users {
+		if stored.Email == user.Email {
+			return nil, gorm.ErrDuplicatedKey
+		}
+	}
+
+	repo.nextID++
+	now := time.Now()
+	user.ID = repo.nextID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
+
+	stored := *user
+	repo.users[stored.ID] = &stored
+	return user, nil
+}
+
+func (repo *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	for _, stored := range repo.users {
+		if stored.Email == email {
+			user := *stored
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (repo *MemoryUserRepository) FindById(ctx context.Context, id uint) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stored, ok := repo.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	user := *stored
+	return &user, nil
+}
+
+func (repo *MemoryUserRepository) GetUsers(ctx context.Context, search string, limit, offset uint) ([]User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	users := repo.search(search)
+	slices.SortFunc(users, func(a, b User) int { return int(b.ID) - int(a.ID) })
+
+	if offset >= uint(len(users)) {
+		return []User{}, nil
+	}
+	users = users[offset:]
+	if limit < uint(len(users)) {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (repo *MemoryUserRepository) GetUsersCount(ctx context.Context, search string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return int64(len(repo.search(search))), nil
+}
+
+func (repo *MemoryUserRepository) SetDisabled(ctx context.Context, id uint, disabled bool) (*User, error) {
+	return repo.update(ctx, id, func(user *User) { user.Disabled = disabled })
+}
+
+func (repo *MemoryUserRepository) SetRole(ctx context.Context, id uint, role string) (*User, error) {
+	return repo.update(ctx, id, func(user *User) { user.Role = role })
+}
+
+func (repo *MemoryUserRepository) PromoteAdmins(ctx context.Context, emails []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, stored := range repo.users {
+		if slices.Contains(emails, stored.Email) {
+			stored.Role = RoleAdmin
+			stored.UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// Поиск по подстроке без учёта регистра, как ILIKE в Postgres
+func (repo *MemoryUserRepository) search(search string) []User {
+	search = strings.ToLower(search)
+	users := make([]User, 0, len(repo.users))
+	for _, stored := range repo.users {
+		if search == "" ||
+			strings.Contains(strings.ToLower(stored.Email), search) ||
+			strings.Contains(strings.ToLower(stored.Name), search) {
+			users = append(users, *stored)
+		}
+	}
+	return users
+}
+
+func (repo *MemoryUserRepository) update(ctx context.Context, id uint, apply func(user *User)) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	apply(stored)
+	stored.UpdatedAt = time.Now()
+
+	user := *stored
+	return &user, nil
+}
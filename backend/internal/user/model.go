@@ -2,11 +2,18 @@ package user
 
 import "gorm.io/gorm"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	gorm.Model
 	Email    string `gorm:"not null;uniqueIndex:idx_email"`
 	Password string `gorm:"not null"`
 	Name     string `gorm:"not null"`
+	Role     string `gorm:"not null;default:user"`
+	Disabled bool   `gorm:"not null;default:false"`
 }
 
 func NewUser(email, password, name string) *User {
@@ -14,5 +21,10 @@ func NewUser(email, password, name string) *User {
 		Email:    email,
 		Password: password,
 		Name:     name,
+		Role:     RoleUser,
 	}
-}
\ No newline at end of file
+}
+
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
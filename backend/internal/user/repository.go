@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"linkshortener/pkg/db"
 
 	"gorm.io/gorm"
@@ -14,17 +15,23 @@ func NewUserRepository(db *db.Db) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (repo *UserRepository) Create(user *User) (*User, error) {
-	result := repo.db.DB.Table("users").Create(user)
+func (repo *UserRepository) Create(ctx context.Context, user *User) (*User, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	result := tx.Table("users").Create(user)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return user, nil
 }
 
-func (repo *UserRepository) FindByEmail(email string) (*User, error) {
+func (repo *UserRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
 	var user User
-	result := repo.db.DB.Table("users").First(&user, "email = ?", email)
+	result := tx.Table("users").First(&user, "email = ?", email)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -33,3 +40,94 @@ func (repo *UserRepository) FindByEmail(email string) (*User, error) {
 	}
 	return &user, nil
 }
+
+func (repo *UserRepository) FindById(ctx context.Context, id uint) (*User, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var user User
+	result := tx.Table("users").First(&user, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+func (repo *UserRepository) GetUsers(ctx context.Context, search string, limit, offset uint) ([]User, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var users []User
+
+	result := repo.search(tx, search).
+		Order("id DESC").
+		Limit(int(limit)).
+		Offset(int(offset)).
+		Find(&users)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return users, nil
+}
+
+func (repo *UserRepository) GetUsersCount(ctx context.Context, search string) (int64, error) {
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	var count int64
+	result := repo.search(tx, search).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+func (repo *UserRepository) SetDisabled(ctx context.Context, id uint, disabled bool) (*User, error) {
+	return repo.updateColumn(ctx, id, "disabled", disabled)
+}
+
+func (repo *UserRepository) SetRole(ctx context.Context, id uint, role string) (*User, error) {
+	return repo.updateColumn(ctx, id, "role", role)
+}
+
+// Назначение роли администратора пользователям из конфигурации
+func (repo *UserRepository) PromoteAdmins(ctx context.Context, emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	result := tx.Model(&User{}).
+		Where("email IN ?", emails).
+		Update("role", RoleAdmin)
+	return result.Error
+}
+
+func (repo *UserRepository) search(tx *gorm.DB, search string) *gorm.DB {
+	query := tx.Model(&User{})
+	if search != "" {
+		pattern := "%" + search + "%"
+		like := repo.db.ILike()
+		query = query.Where("email "+like+" ? OR name "+like+" ?", pattern, pattern)
+	}
+	return query
+}
+
+func (repo *UserRepository) updateColumn(ctx context.Context, id uint, column string, value any) (*User, error) {
+	user, err := repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, cancel := repo.db.Query(ctx)
+	defer cancel()
+
+	result := tx.Model(user).Update(column, value)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return user, nil
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"fmt"
+	"linkshortener/config"
+	"linkshortener/pkg/db"
+	"log/slog"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func waitForDB(config *config.Config) error {
+	deadline := time.Now().Add(config.DB.ConnectTimeout)
+	for {
+		db, err := gorm.Open(postgres.Open(config.DB.URL), &gorm.Config{})
+		if err == nil {
+			if sqlDB, err := db.DB(); err == nil {
+				if err := sqlDB.Ping(); err == nil {
+					slog.Info("database connected")
+					sqlDB.Close()
+					return nil
+				}
+				sqlDB.Close()
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("database is not reachable after %s", config.DB.ConnectTimeout)
+		}
+		slog.Info("waiting for database")
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// Ждёт, пока база станет доступна, и открывает пул соединений
+func Connect(cfg *config.Config) (*db.Db, error) {
+	// Файл SQLite открывается сразу, ждать нечего
+	if cfg.DB.Driver == config.DBDriverPostgres {
+		if err := waitForDB(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return db.NewDb(cfg)
+}
@@ -0,0 +1,387 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"linkshortener/config"
+	"linkshortener/pkg/db"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Ключ advisory lock, под которым миграции выполняются не более чем одним инстансом
+const lockKey = 4_815_162_342
+
+// Различия СУБД: каталог с миграциями, таблица версий и блокировка на время миграции
+type dialect struct {
+	dir          string
+	versionTable string
+	lock         string
+	unlock       string
+	// Приводит скрипт к синтаксису СУБД перед выполнением
+	prepare func(ctx context.Context, tx *sql.Tx, script string) (string, error)
+}
+
+var dialects = map[string]dialect{
+	config.DBDriverPostgres: {
+		dir: "postgres",
+		versionTable: `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version    BIGINT PRIMARY KEY,
+				name       TEXT        NOT NULL,
+				applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+			)`,
+		lock:   `SELECT pg_advisory_lock($1)`,
+		unlock: `SELECT pg_advisory_unlock($1)`,
+	},
+	// Файл SQLite принадлежит одному процессу, а запись и так сериализуется самой БД
+	config.DBDriverSQLite: {
+		dir: "sqlite",
+		versionTable: `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version    INTEGER PRIMARY KEY,
+				name       TEXT      NOT NULL,
+				applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+		prepare: prepareSQLite,
+	},
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var addColumnIfNotExists = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+IF\s+NOT\s+EXISTS\s+(\w+)\s+([^;]*);`)
+
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+func NewMigrator(database *db.Db) (*Migrator, error) {
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	dialect, ok := dialects[database.Driver()]
+	if !ok {
+		return nil, fmt.Errorf("migrations are not available for %s", database.Driver())
+	}
+
+	migrations, err := load(files, dialect.dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: sqlDB, dialect: dialect, migrations: migrations}, nil
+}
+
+// Читает пары <version>_<name>.up.sql / .down.sql и упорядочивает их по версии
+func load(files fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("migration %s: version must be positive", entry.Name())
+		}
+
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) Current(ctx context.Context) (int, error) {
+	if err := m.ensureVersionTable(ctx, m.db); err != nil {
+		return 0, err
+	}
+	return currentVersion(ctx, m.db)
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureVersionTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Откатывает последнюю применённую миграцию
+func (m *Migrator) Down(ctx context.Context) error {
+	current, err := m.Current(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := 0
+	for _, migration := range m.migrations {
+		if migration.Version < current {
+			target = migration.Version
+		}
+	}
+	return m.To(ctx, target)
+}
+
+// Применяет или откатывает миграции до указанной версии (0 — пустая схема)
+func (m *Migrator) To(ctx context.Context, target int) error {
+	if target != 0 && !slices.ContainsFunc(m.migrations, func(migration Migration) bool { return migration.Version == target }) {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+
+	// Advisory lock держится на уровне сессии, поэтому все шаги идут через одно соединение
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect.lock != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.lock, lockKey); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), m.dialect.unlock, lockKey)
+	}
+
+	if err := m.ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	// Версия читается уже под блокировкой: другой инстанс мог успеть применить миграции
+	current, err := currentVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if target >= current {
+		for _, migration := range m.migrations {
+			if migration.Version > current && migration.Version <= target {
+				if err := m.apply(ctx, conn, migration, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= current && migration.Version > target {
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Применяет миграции при autoApply, иначе отказывается работать на устаревшей схеме
+func (m *Migrator) Ensure(ctx context.Context, autoApply bool) error {
+	if autoApply {
+		return m.Up(ctx)
+	}
+
+	current, err := m.Current(ctx)
+	if err != nil {
+		return err
+	}
+	if current < m.Latest() {
+		return fmt.Errorf("%w: version %d, expected %d; run `migrate up` or set DB_AUTO_MIGRATE=true", ErrSchemaBehind, current, m.Latest())
+	}
+	if current > m.Latest() {
+		slog.Warn("database schema is newer than the application", "version", current, "expected", m.Latest())
+	}
+	return nil
+}
+
+// Проверка для readiness: схема не отстаёт от версии приложения
+func (m *Migrator) Check(ctx context.Context) error {
+	current, err := currentVersion(ctx, m.db)
+	if err != nil {
+		return err
+	}
+	if current < m.Latest() {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaBehind, current, m.Latest())
+	}
+	return nil
+}
+
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context, db execQuerier) error {
+	_, err := db.ExecContext(ctx, m.dialect.versionTable)
+	return err
+}
+
+func currentVersion(ctx context.Context, db execQuerier) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// Каждая миграция выполняется в своей транзакции вместе с записью в schema_migrations
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+
+	if m.dialect.prepare != nil {
+		if script, err = m.dialect.prepare(ctx, tx, script); err != nil {
+			return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+		}
+	}
+
+	startTime := time.Now()
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	slog.Info("migration applied",
+		"version", migration.Version,
+		"name", migration.Name,
+		"direction", direction,
+		"duration", time.Since(startTime),
+	)
+	return nil
+}
+
+// SQLite не знает ADD COLUMN IF NOT EXISTS: такой шаг выполняется обычным ADD COLUMN, только если
+// таблица уже есть, а колонки в ней нет. Новую таблицу создаёт CREATE TABLE выше в том же скрипте
+func prepareSQLite(ctx context.Context, tx *sql.Tx, script string) (string, error) {
+	var prepareErr error
+	script = addColumnIfNotExists.ReplaceAllStringFunc(script, func(statement string) string {
+		match := addColumnIfNotExists.FindStringSubmatch(statement)
+		table, column, definition := match[1], match[2], match[3]
+
+		var columns, existing int
+		err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*), COUNT(CASE WHEN name = $2 THEN 1 END) FROM pragma_table_info($1)`, table, column,
+		).Scan(&columns, &existing)
+		if err != nil {
+			prepareErr = errors.Join(prepareErr, err)
+			return statement
+		}
+		if columns == 0 || existing > 0 {
+			return ""
+		}
+		return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, definition)
+	})
+	return script, prepareErr
+}
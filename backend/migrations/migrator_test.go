@@ -0,0 +1,251 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"linkshortener/config"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	for _, dialect := range dialects {
+		migrations, err := load(files, dialect.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("%s: expected embedded migrations", dialect.dir)
+		}
+		for i, migration := range migrations {
+			if i > 0 && migration.Version <= migrations[i-1].Version {
+				t.Fatalf("%s: migrations are not ordered: %d after %d", dialect.dir, migration.Version, migrations[i-1].Version)
+			}
+		}
+	}
+}
+
+// Версии и имена миграций должны совпадать во всех СУБД, иначе номер версии схемы теряет смысл
+func TestDialectsHaveSameMigrations(t *testing.T) {
+	postgres, err := load(files, dialects[config.DBDriverPostgres].dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := load(files, dialects[config.DBDriverSQLite].dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("postgres has %d migrations, sqlite has %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Fatalf("migration mismatch: postgres %d_%s, sqlite %d_%s", postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestLoadRejectsMissingDown(t *testing.T) {
+	files := fstest.MapFS{
+		"sql/0001_init.up.sql":   {Data: []byte("SELECT 1;")},
+		"sql/0001_init.down.sql": {Data: []byte("SELECT 1;")},
+		"sql/0002_next.up.sql":   {Data: []byte("SELECT 1;")},
+	}
+	if _, err := load(files, "sql"); err == nil {
+		t.Fatal("expected error for migration without down file")
+	}
+}
+
+func TestLoadRejectsUnexpectedFiles(t *testing.T) {
+	files := fstest.MapFS{
+		"sql/init.sql": {Data: []byte("SELECT 1;")},
+	}
+	if _, err := load(files, "sql"); err == nil {
+		t.Fatal("expected error for file without version")
+	}
+}
+
+func newSQLiteMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	cfg := config.Default()
+	cfg.DB.Driver = config.DBDriverSQLite
+	cfg.DB.URL = filepath.Join(t.TempDir(), "test.db")
+
+	database, err := Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := NewMigrator(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { migrator.db.Close() })
+	return migrator
+}
+
+func TestMigratorSQLiteUpDown(t *testing.T) {
+	ctx := context.Background()
+	migrator := newSQLiteMigrator(t)
+
+	if err := migrator.Ensure(ctx, false); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("expected ErrSchemaBehind on empty database, got %v", err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("expected schema to be up to date: %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Fatalf("migration %d_%s is not applied", status.Version, status.Name)
+		}
+	}
+
+	if _, err := migrator.db.Exec(`INSERT INTO users (email, password, name, role) VALUES ('a@b.c', 'x', 'a', 'root')`); err == nil {
+		t.Fatal("expected role check to reject unknown role")
+	}
+	if _, err := migrator.db.Exec(`INSERT INTO audit_logs (created_at, action, target) VALUES (CURRENT_TIMESTAMP, 'a', 'b')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.db.Exec(`DELETE FROM audit_logs`); err == nil {
+		t.Fatal("expected audit_logs to be append-only")
+	}
+
+	if err := migrator.Down(ctx); err != nil {
+		t.Fatal(err)
+	}
+	current, err := migrator.Current(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != migrator.Latest()-1 {
+		t.Fatalf("expected version %d after down, got %d", migrator.Latest()-1, current)
+	}
+
+	if err := migrator.To(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := migrator.Current(ctx); current != 0 {
+		t.Fatalf("expected empty schema, got version %d", current)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrations must apply again after full rollback: %v", err)
+	}
+}
+
+func TestMigratorMergesDuplicateStats(t *testing.T) {
+	ctx := context.Background()
+	migrator := newSQLiteMigrator(t)
+	if err := migrator.To(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.db.Exec(`INSERT INTO links (id, original_url, hash) VALUES (1, 'https://example.com', 'abc')`); err != nil {
+		t.Fatal(err)
+	}
+	for _, clicks := range []int{2, 3} {
+		if _, err := migrator.db.Exec(`INSERT INTO stats (link_id, click_count, date) VALUES (1, ?, '2024-01-31')`, clicks); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows, clicks int
+	if err := migrator.db.QueryRow(`SELECT COUNT(*), SUM(click_count) FROM stats`).Scan(&rows, &clicks); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 || clicks != 5 {
+		t.Fatalf("expected duplicates merged into one row with 5 clicks, got %d rows with %d clicks", rows, clicks)
+	}
+	if _, err := migrator.db.Exec(`INSERT INTO stats (link_id, click_count, date) VALUES (1, 1, '2024-01-31')`); err == nil {
+		t.Fatal("expected unique (link_id, date) to reject a second row")
+	}
+}
+
+// Модели в том виде, в каком их создавал AutoMigrate до версионированных миграций
+type baselineLink struct {
+	gorm.Model
+	OriginalURL string          `gorm:"not null"`
+	Hash        string          `gorm:"not null;uniqueIndex:idx_hash;size:12"`
+	Stats       []baselineStats `gorm:"foreignKey:LinkId"`
+}
+
+func (baselineLink) TableName() string { return "links" }
+
+type baselineUser struct {
+	gorm.Model
+	Email    string `gorm:"not null;uniqueIndex:idx_email"`
+	Password string `gorm:"not null"`
+	Name     string `gorm:"not null"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineStats struct {
+	gorm.Model
+	LinkId     uint
+	ClickCount uint
+	Date       datatypes.Date
+}
+
+func (baselineStats) TableName() string { return "stats" }
+
+func TestMigratorAdoptsAutoMigrateSchema(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.DB.Driver = config.DBDriverSQLite
+	cfg.DB.URL = filepath.Join(t.TempDir(), "test.db")
+
+	database, err := Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.AutoMigrate(&baselineLink{}, &baselineUser{}, &baselineStats{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Create(&baselineUser{Email: "a@b.c", Password: "x", Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Create(&baselineLink{OriginalURL: "https://example.com", Hash: "abc"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := NewMigrator(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { migrator.db.Close() })
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var role string
+	var disabled bool
+	if err := migrator.db.QueryRow(`SELECT role, disabled FROM users WHERE email = 'a@b.c'`).Scan(&role, &disabled); err != nil {
+		t.Fatal(err)
+	}
+	if role != "user" || disabled {
+		t.Fatalf("expected existing user to get default role and stay enabled, got %q %v", role, disabled)
+	}
+	if err := migrator.db.QueryRow(`SELECT disabled FROM links WHERE hash = 'abc'`).Scan(&disabled); err != nil {
+		t.Fatal(err)
+	}
+	if disabled {
+		t.Fatal("expected existing link to stay enabled")
+	}
+	if _, err := migrator.db.Exec(`UPDATE users SET role = 'root'`); err == nil {
+		t.Fatal("expected role check to apply to adopted table")
+	}
+}
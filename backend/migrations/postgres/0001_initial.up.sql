@@ -0,0 +1,67 @@
+-- Исходная схема, совпадающая с той, что создавал GORM AutoMigrate.
+-- IF NOT EXISTS позволяет принять под версионирование уже существующую базу.
+CREATE TABLE IF NOT EXISTS links (
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ,
+    deleted_at   TIMESTAMPTZ,
+    original_url TEXT        NOT NULL,
+    hash         VARCHAR(12) NOT NULL,
+    disabled     BOOLEAN     NOT NULL DEFAULT FALSE
+);
+-- В базе, созданной AutoMigrate до появления блокировок, таблица есть, а колонки нет
+ALTER TABLE links ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS idx_links_deleted_at ON links (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_hash ON links (hash);
+
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    email      TEXT    NOT NULL,
+    password   TEXT    NOT NULL,
+    name       TEXT    NOT NULL,
+    role       TEXT    NOT NULL DEFAULT 'user',
+    disabled   BOOLEAN NOT NULL DEFAULT FALSE
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS stats (
+    id          BIGSERIAL PRIMARY KEY,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ,
+    link_id     BIGINT,
+    click_count BIGINT,
+    date        DATE,
+    CONSTRAINT fk_links_stats FOREIGN KEY (link_id) REFERENCES links (id)
+);
+CREATE INDEX IF NOT EXISTS idx_stats_deleted_at ON stats (deleted_at);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          BIGSERIAL PRIMARY KEY,
+    created_at  TIMESTAMPTZ NOT NULL,
+    actor_id    BIGINT,
+    actor_email TEXT,
+    action      TEXT NOT NULL,
+    target      TEXT NOT NULL,
+    ip          TEXT,
+    user_agent  TEXT,
+    before      JSONB,
+    after       JSONB
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target);
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key        VARCHAR(255) PRIMARY KEY,
+    tokens     DECIMAL     NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
@@ -0,0 +1,3 @@
+-- Роль, которую раньше проверял только валидатор запроса
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin');
+ALTER TABLE users ADD CONSTRAINT chk_users_role CHECK (role IN ('user', 'admin'));
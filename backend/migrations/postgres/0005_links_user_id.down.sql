@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_links_user_id;
+ALTER TABLE links DROP COLUMN user_id;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_stats_link_date;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS rate_limit_buckets;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS stats;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS links;
@@ -0,0 +1,67 @@
+-- Та же схема, что и migrations/postgres/0001_initial.up.sql, в типах SQLite.
+-- ADD COLUMN IF NOT EXISTS выполняет мигратор (prepareSQLite), сама SQLite такого синтаксиса не знает
+CREATE TABLE IF NOT EXISTS links (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   DATETIME,
+    updated_at   DATETIME,
+    deleted_at   DATETIME,
+    original_url TEXT        NOT NULL,
+    hash         VARCHAR(12) NOT NULL,
+    disabled     BOOLEAN     NOT NULL DEFAULT FALSE
+);
+-- В базе, созданной AutoMigrate до появления блокировок, таблица есть, а колонки нет
+ALTER TABLE links ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS idx_links_deleted_at ON links (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_hash ON links (hash);
+
+CREATE TABLE IF NOT EXISTS users (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    email      TEXT    NOT NULL,
+    password   TEXT    NOT NULL,
+    name       TEXT    NOT NULL,
+    role       TEXT    NOT NULL DEFAULT 'user',
+    disabled   BOOLEAN NOT NULL DEFAULT FALSE
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS stats (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    deleted_at  DATETIME,
+    link_id     INTEGER,
+    click_count INTEGER,
+    date        DATE,
+    CONSTRAINT fk_links_stats FOREIGN KEY (link_id) REFERENCES links (id)
+);
+CREATE INDEX IF NOT EXISTS idx_stats_deleted_at ON stats (deleted_at);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at  DATETIME NOT NULL,
+    actor_id    INTEGER,
+    actor_email TEXT,
+    action      TEXT NOT NULL,
+    target      TEXT NOT NULL,
+    ip          TEXT,
+    user_agent  TEXT,
+    before      JSON,
+    after       JSON
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target);
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key        VARCHAR(255) PRIMARY KEY,
+    tokens     REAL     NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS audit_logs_append_only_delete;
+DROP TRIGGER IF EXISTS audit_logs_append_only_update;
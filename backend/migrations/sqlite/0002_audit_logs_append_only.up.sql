@@ -0,0 +1,12 @@
+-- Журнал аудита только пополняется: UPDATE и DELETE запрещены на уровне БД
+CREATE TRIGGER IF NOT EXISTS audit_logs_append_only_update
+    BEFORE UPDATE ON audit_logs
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_logs_append_only_delete
+    BEFORE DELETE ON audit_logs
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is append-only');
+END;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS chk_users_role_update;
+DROP TRIGGER IF EXISTS chk_users_role_insert;
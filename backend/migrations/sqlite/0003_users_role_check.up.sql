@@ -0,0 +1,17 @@
+-- Роль, которую раньше проверял только валидатор запроса.
+-- SQLite не умеет добавлять CHECK к существующей таблице, поэтому ограничение держат триггеры
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin');
+
+CREATE TRIGGER IF NOT EXISTS chk_users_role_insert
+    BEFORE INSERT ON users
+    WHEN NEW.role NOT IN ('user', 'admin')
+BEGIN
+    SELECT RAISE(ABORT, 'CHECK constraint failed: chk_users_role');
+END;
+
+CREATE TRIGGER IF NOT EXISTS chk_users_role_update
+    BEFORE UPDATE OF role ON users
+    WHEN NEW.role NOT IN ('user', 'admin')
+BEGIN
+    SELECT RAISE(ABORT, 'CHECK constraint failed: chk_users_role');
+END;
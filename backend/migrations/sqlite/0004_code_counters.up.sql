@@ -0,0 +1,11 @@
+-- Общие для инстансов счётчики коротких кодов: каждый инстанс резервирует из них блоки значений.
+-- Счётчик ссылок начинается с MAX(id), а не с 0: до этой миграции стратегии counter и sequential
+-- при запуске продолжали нумерацию с наибольшего id, и коды существующих ссылок — это значения не больше
+-- него. С нуля счётчик заново выдал бы их все, и каждое создание ссылки упиралось бы в уникальный hash,
+-- пока счётчик их не минует: повторов вставки (5) на это не хватит. Немногие значения выше MAX(id),
+-- которые успел израсходовать пул кодов, отсеивает проверка пула и повтор вставки
+CREATE TABLE code_counters (
+    name  TEXT    PRIMARY KEY,
+    value INTEGER NOT NULL
+);
+INSERT INTO code_counters (name, value) SELECT 'links', COALESCE(MAX(id), 0) FROM links;
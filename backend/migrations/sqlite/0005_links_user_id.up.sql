@@ -0,0 +1,3 @@
+-- Автор ссылки для статистики пользователя; у ранее созданных ссылок автора нет
+ALTER TABLE links ADD COLUMN IF NOT EXISTS user_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_links_user_id ON links (user_id);
@@ -0,0 +1,16 @@
+-- Одна строка статистики на ссылку и день: конкурентные записи кликов складываются через
+-- INSERT ... ON CONFLICT вместо чтения и вставки, которые порождали дубли. Дубли, накопленные
+-- до этой миграции, сливаются в строку с наименьшим id
+UPDATE stats SET click_count = (
+    SELECT SUM(duplicate.click_count) FROM stats duplicate
+    WHERE duplicate.link_id = stats.link_id AND duplicate.date = stats.date
+)
+WHERE id IN (
+    SELECT MIN(id) FROM stats
+    WHERE link_id IS NOT NULL AND date IS NOT NULL
+    GROUP BY link_id, date HAVING COUNT(*) > 1
+);
+DELETE FROM stats
+WHERE link_id IS NOT NULL AND date IS NOT NULL
+  AND id NOT IN (SELECT MIN(id) FROM stats WHERE link_id IS NOT NULL AND date IS NOT NULL GROUP BY link_id, date);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stats_link_date ON stats (link_id, date);
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2)
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, time.Minute)
+
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Set("c", 3, time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	if value, ok := c.Get("a"); !ok || value != 1 {
+		t.Fatalf("expected a=1, got %d %v", value, ok)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	now := time.Now()
+	c := NewLRU[string, int](10)
+	c.now = func() time.Time { return now }
+
+	c.Set("short", 1, time.Second)
+	c.Set("long", 2, time.Hour)
+	now = now.Add(time.Minute)
+
+	if _, ok := c.Get("short"); ok {
+		t.Fatal("expected short entry to expire")
+	}
+	if _, ok := c.Get("long"); !ok {
+		t.Fatal("expected long entry to stay")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected expired entry to be removed, got %d entries", c.Len())
+	}
+}
+
+func TestLRUDelete(t *testing.T) {
+	c := NewLRU[string, int](10)
+	c.Set("a", 1, time.Minute)
+	c.Delete("a")
+	c.Delete("missing")
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected a to be deleted")
+	}
+
+	c.Set("b", 2, time.Minute)
+	c.Set("c", 3, time.Minute)
+	c.Clear()
+	if _, ok := c.Get("b"); ok || c.Len() != 0 {
+		t.Fatalf("expected empty cache after Clear, got %d entries", c.Len())
+	}
+}
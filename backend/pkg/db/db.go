@@ -1,20 +1,154 @@
 package db
 
 import (
+	"context"
+	"fmt"
 	"linkshortener/config"
+	"strings"
+	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 type Db struct {
 	*gorm.DB
+	queryTimeout  time.Duration
+	reportTimeout time.Duration
+	// nil, если реплики не заданы
+	readYourWrites *ReadYourWrites
 }
 
-func NewDb(config *config.Config) *Db {
-	db, err := gorm.Open(postgres.Open(config.DB.URL), &gorm.Config{})
+func NewDb(config *config.Config) (*Db, error) {
+	db, err := gorm.Open(dialector(config.DB.Driver, config.DB.URL), &gorm.Config{
+		Logger: newSlogLogger(),
+		// Нарушения уникальности приходят как gorm.ErrDuplicatedKey, без разбора кодов Postgres в обработчиках
+		TranslateError: true,
+		PrepareStmt:    config.DB.PrepareStmt,
+	})
 	if err != nil {
-		panic("failed to connect database")
+		return nil, fmt.Errorf("failed to connect database: %w", err)
 	}
-	return &Db{db}
-}
\ No newline at end of file
+	if err := db.Use(TracingPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(config.DB.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.DB.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.DB.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.DB.ConnMaxIdleTime)
+
+	result := &Db{
+		DB:            db,
+		queryTimeout:  config.DB.QueryTimeout,
+		reportTimeout: config.DB.ReportTimeout,
+	}
+	if len(config.DB.ReplicaURLs) > 0 {
+		if err := result.useReplicas(config); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Чтения вне транзакций (редиректы, статистика, списки) уходят на реплики, запись и транзакции — на основную БД
+func (db *Db) useReplicas(config *config.Config) error {
+	replicas := make([]gorm.Dialector, 0, len(config.DB.ReplicaURLs))
+	for _, replicaURL := range config.DB.ReplicaURLs {
+		replicas = append(replicas, dialector(config.DB.Driver, replicaURL))
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxOpenConns(config.DB.MaxOpenConns).
+		SetMaxIdleConns(config.DB.MaxIdleConns).
+		SetConnMaxLifetime(config.DB.ConnMaxLifetime).
+		SetConnMaxIdleTime(config.DB.ConnMaxIdleTime)
+	if err := db.Use(resolver); err != nil {
+		return fmt.Errorf("failed to register read replicas: %w", err)
+	}
+
+	db.readYourWrites = NewReadYourWrites(config.DB.ReadYourWritesWindow)
+	if err := db.Use(db.readYourWrites); err != nil {
+		return fmt.Errorf("failed to register read-your-writes plugin: %w", err)
+	}
+	return nil
+}
+
+// Сессия GORM с контекстом запроса, ограниченным DB_QUERY_TIMEOUT. Отмена контекста
+// (клиент закрыл соединение) прерывает запрос к БД и освобождает соединение
+func (db *Db) Query(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return db.withTimeout(ctx, db.queryTimeout)
+}
+
+// То же для тяжёлых агрегирующих запросов (отчёты), с таймаутом DB_REPORT_TIMEOUT
+func (db *Db) Report(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return db.withTimeout(ctx, db.reportTimeout)
+}
+
+func (db *Db) withTimeout(ctx context.Context, timeout time.Duration) (*gorm.DB, context.CancelFunc) {
+	if timeout <= 0 {
+		return db.session(ctx), func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return db.session(ctx), cancel
+}
+
+func (db *Db) session(ctx context.Context) *gorm.DB {
+	tx := db.DB.WithContext(ctx)
+	if primary(ctx) || db.readYourWrites != nil && db.readYourWrites.Pinned(ctx) {
+		tx = tx.Clauses(dbresolver.Write)
+	}
+	return tx
+}
+
+// Имя СУБД: config.DBDriverPostgres или config.DBDriverSQLite
+func (db *Db) Driver() string {
+	return db.Dialector.Name()
+}
+
+func (db *Db) IsSQLite() bool {
+	return db.Driver() == config.DBDriverSQLite
+}
+
+// Регистронезависимый LIKE: в SQLite обычный LIKE и так не различает регистр (для ASCII)
+func (db *Db) ILike() string {
+	if db.IsSQLite() {
+		return "LIKE"
+	}
+	return "ILIKE"
+}
+
+func dialector(driver, dsn string) gorm.Dialector {
+	if driver == config.DBDriverSQLite {
+		return sqlite.Open(sqliteDSN(dsn))
+	}
+	return postgres.Open(dsn)
+}
+
+// Включает внешние ключи (в SQLite они выключены по умолчанию), WAL и ожидание блокировки
+// вместо мгновенной ошибки SQLITE_BUSY, если эти pragma не заданы в DSN явно
+func sqliteDSN(dsn string) string {
+	pragmas := []string{"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"}
+	for _, pragma := range pragmas {
+		name := pragma[:strings.Index(pragma, "(")]
+		if strings.Contains(dsn, "_pragma="+name) {
+			continue
+		}
+		if strings.Contains(dsn, "?") {
+			dsn += "&"
+		} else {
+			dsn += "?"
+		}
+		dsn += "_pragma=" + pragma
+	}
+	return dsn
+}
@@ -0,0 +1,94 @@
+package db_test
+
+import (
+	"context"
+	"linkshortener/config"
+	"linkshortener/pkg/db"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+// Основная БД и «реплика» — разные файлы без репликации, поэтому по содержимому видно, куда ушёл запрос
+func TestReplicasAndReadYourWrites(t *testing.T) {
+	dir := t.TempDir()
+	primaryPath := filepath.Join(dir, "primary.db")
+	replicaPath := filepath.Join(dir, "replica.db")
+
+	replica, err := gorm.Open(sqlite.Open(replicaPath), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replica.AutoMigrate(&item{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := replica.Create(&item{Name: "replica"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{DB: config.DbConfig{
+		Driver:               config.DBDriverSQLite,
+		URL:                  primaryPath,
+		QueryTimeout:         time.Second,
+		ReportTimeout:        time.Second,
+		PrepareStmt:          true,
+		ReplicaURLs:          []string{replicaPath},
+		ReadYourWritesWindow: time.Minute,
+	}}
+	database, err := db.NewDb(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := database.Exec("CREATE TABLE items (id integer PRIMARY KEY, name text)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(ctx context.Context) string {
+		t.Helper()
+		tx, cancel := database.Query(ctx)
+		defer cancel()
+
+		var found item
+		if err := tx.Order("id").First(&found).Error; err != nil {
+			t.Fatal(err)
+		}
+		return found.Name
+	}
+
+	author := db.WithSession(context.Background(), "1")
+	if got := read(author); got != "replica" {
+		t.Fatalf("expected read from replica before any write, got %q", got)
+	}
+
+	tx, cancel := database.Query(author)
+	defer cancel()
+	if err := tx.Create(&item{Name: "primary"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if got := read(author); got != "primary" {
+		t.Fatalf("expected author to read own write from primary, got %q", got)
+	}
+	if got := read(db.WithSession(context.Background(), "2")); got != "replica" {
+		t.Fatalf("expected other session to read from replica, got %q", got)
+	}
+	if got := read(context.Background()); got != "replica" {
+		t.Fatalf("expected anonymous read from replica, got %q", got)
+	}
+	if got := read(db.WithPrimary(context.Background())); got != "primary" {
+		t.Fatalf("expected read pinned to primary, got %q", got)
+	}
+}
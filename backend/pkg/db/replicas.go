@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type sessionKey struct{}
+
+type primaryKey struct{}
+
+// Привязывает к контексту ключ сессии (например, ID пользователя) для read-your-writes
+func WithSession(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, key)
+}
+
+// Направляет все чтения с этим контекстом на основную БД, например при заполнении кэша,
+// который иначе мог бы надолго запомнить ещё не дошедшие до реплики данные
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func primary(ctx context.Context) bool {
+	pinned, _ := ctx.Value(primaryKey{}).(bool)
+	return pinned
+}
+
+func session(ctx context.Context) string {
+	key, _ := ctx.Value(sessionKey{}).(string)
+	return key
+}
+
+// Плагин GORM, запоминающий сессии, которые недавно писали в БД: их чтения в течение window
+// идут на основную БД, чтобы пользователь сразу видел свои изменения, не дожидаясь репликации
+type ReadYourWrites struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	writes map[string]time.Time
+}
+
+func NewReadYourWrites(window time.Duration) *ReadYourWrites {
+	return &ReadYourWrites{window: window, writes: make(map[string]time.Time)}
+}
+
+func (p *ReadYourWrites) Name() string {
+	return "read_your_writes"
+}
+
+func (p *ReadYourWrites) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().After("gorm:create").Register("read_your_writes:create", p.after); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("read_your_writes:update", p.after); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("read_your_writes:delete", p.after)
+}
+
+func (p *ReadYourWrites) after(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return
+	}
+	if key := session(db.Statement.Context); key != "" {
+		p.mark(key, time.Now())
+	}
+}
+
+func (p *ReadYourWrites) mark(key string, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.writes[key] = now.Add(p.window)
+	// Просроченные записи чистятся попутно, чтобы карта не росла с числом пользователей
+	if len(p.writes) > 1024 {
+		for k, until := range p.writes {
+			if now.After(until) {
+				delete(p.writes, k)
+			}
+		}
+	}
+}
+
+func (p *ReadYourWrites) Pinned(ctx context.Context) bool {
+	key := session(ctx)
+	if key == "" {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until, ok := p.writes[key]
+	return ok && time.Now().Before(until)
+}
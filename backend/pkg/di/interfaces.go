@@ -1,8 +1,11 @@
 package di
 
 import (
+	"context"
 	"linkshortener/internal/user"
 	"linkshortener/pkg/event"
+	"net/http"
+	"time"
 )
 
 type IEventBus interface {
@@ -10,11 +13,72 @@ type IEventBus interface {
 	Subscribe() <-chan event.Event
 }
 
-type IStatsRepository interface {
-	AddClick(linkId uint) error
+// Интерфейсы хранилищ ссылок, статистики и аудита параметризованы их моделями:
+// пакеты link, stats и audit сами зависят от di, поэтому di не может импортировать их типы.
+// Отсутствующая запись — gorm.ErrRecordNotFound, нарушение уникальности — gorm.ErrDuplicatedKey.
+
+type ILinkRepository[Link any] interface {
+	GetByHash(ctx context.Context, hash string) (*Link, error)
+	Create(ctx context.Context, link *Link) (*Link, error)
+	Update(ctx context.Context, link *Link) (*Link, error)
+	GetById(ctx context.Context, id uint) (*Link, error)
+	FindById(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint) error
+	SetDisabled(ctx context.Context, id uint, disabled bool) (*Link, error)
+	GetLinks(ctx context.Context, limit, offset uint) ([]Link, error)
+	GetLinksCount(ctx context.Context) (int64, error)
+	// Число занятых hash: удалённые ссылки остаются в уникальном индексе и тоже считаются
+	GetHashesCount(ctx context.Context) (int64, error)
+	IUserLinks
+}
+
+// Id всех ссылок пользователя, включая удалённые: их клики остаются в статистике
+type IUserLinks interface {
+	GetUserLinkIds(ctx context.Context, userId uint) ([]uint, error)
+}
+
+type IStatsRepository[Response any] interface {
+	// Прибавляет count кликов к сегодняшней статистике ссылки
+	AddClicks(ctx context.Context, linkId uint, count uint) error
+	GetStats(ctx context.Context, by string, startDate, endDate time.Time) (Response, error)
+	// То же только по кликам ссылок linkIds
+	GetLinksStats(ctx context.Context, linkIds []uint, by string, startDate, endDate time.Time) (Response, error)
+}
+
+type IAuditRepository[Entry, Filter any] interface {
+	Create(ctx context.Context, entry *Entry) error
+	GetEntries(ctx context.Context, filter Filter, limit, offset uint) ([]Entry, error)
+	GetEntriesCount(ctx context.Context, filter Filter) (int64, error)
+}
+
+// Кэш ссылок по hash. Отсутствующая ссылка хранится как nil: Get возвращает (nil, true, nil)
+type ILinkCache[Link any] interface {
+	Get(ctx context.Context, hash string) (*Link, bool, error)
+	Set(ctx context.Context, hash string, link *Link, ttl time.Duration) error
+	Delete(ctx context.Context, hashes ...string) error
+	Clear(ctx context.Context) error
+}
+
+// Очередь кликов, которые не удалось записать в БД. Pop забирает пачку в обработку, но клики
+// остаются в буфере, пока пачку не подтвердит Ack; retry — клики пачки, которые нужно вернуть в очередь
+type IClickBuffer interface {
+	Push(ctx context.Context, linkIds ...uint) error
+	Pop(ctx context.Context, max int) (batch string, linkIds []uint, err error)
+	Ack(ctx context.Context, batch string, retry ...uint) error
 }
 
 type IUserRepository interface {
-	Create(user *user.User) (*user.User, error)
-	FindByEmail(email string) (*user.User, error)
+	Create(ctx context.Context, user *user.User) (*user.User, error)
+	// Отсутствующий пользователь — (nil, nil)
+	FindByEmail(ctx context.Context, email string) (*user.User, error)
+	FindById(ctx context.Context, id uint) (*user.User, error)
+	GetUsers(ctx context.Context, search string, limit, offset uint) ([]user.User, error)
+	GetUsersCount(ctx context.Context, search string) (int64, error)
+	SetDisabled(ctx context.Context, id uint, disabled bool) (*user.User, error)
+	SetRole(ctx context.Context, id uint, role string) (*user.User, error)
+	PromoteAdmins(ctx context.Context, emails []string) error
+}
+
+type IAuditLogger interface {
+	Log(r *http.Request, action, target string, before, after any)
 }
@@ -1,26 +1,126 @@
 package event
 
+import (
+	"context"
+	"linkshortener/pkg/metrics"
+	"linkshortener/pkg/tracing"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
 const (
 	LinkClicked = "link.clicked"
+	LinkCreated = "link.created"
+	LinkUpdated = "link.updated"
+	LinkDeleted = "link.deleted"
 )
 
+// Данные событий LinkCreated, LinkUpdated и LinkDeleted: hash-и, по которым ссылка была доступна до и после изменения
+type LinkChanged struct {
+	ID     uint
+	Hashes []string
+}
+
+const queueSize = 1024
+
 type Event struct {
-	Type string
-	Data any
+	Type  string
+	Data  any
+	Trace map[string]string
+	// Пусто для событий этого инстанса; мост между инстансами помечает полученные им события,
+	// чтобы не отправлять их обратно
+	Source string
+}
+
+// Создаёт событие с контекстом трассировки публикующего запроса
+func NewEvent(ctx context.Context, eventType string, data any) Event {
+	ctx, span := tracing.Tracer().Start(ctx, "publish "+eventType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", eventType)),
+	)
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return Event{
+		Type:  eventType,
+		Data:  data,
+		Trace: carrier,
+	}
+}
+
+// Восстанавливает контекст трассировки на стороне потребителя
+func (e Event) Context(ctx context.Context) context.Context {
+	if len(e.Trace) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(e.Trace))
 }
 
 type EventBus struct {
-	bus chan Event
+	mu        sync.RWMutex
+	bus       chan Event
+	closed    bool
+	listeners map[string][]func(Event)
 }
 
 func NewEventBus() *EventBus {
-	return &EventBus{bus: make(chan Event)}
+	return &EventBus{
+		bus:       make(chan Event, queueSize),
+		listeners: make(map[string][]func(Event)),
+	}
 }
 
+// Регистрирует обработчик, который вызывается синхронно при публикации события этого типа,
+// ещё до постановки в очередь. Подходит для быстрых действий вроде сброса кэша
+func (e *EventBus) On(eventType string, listener func(Event)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.listeners[eventType] = append(e.listeners[eventType], listener)
+}
+
+// Публикация не блокирует обработчик запроса: при переполненной очереди событие отбрасывается
 func (e *EventBus) Publish(event Event) {
-	e.bus <- event
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, listener := range e.listeners[event.Type] {
+		listener(event)
+	}
+
+	if e.closed {
+		metrics.EventsDropped.WithLabelValues(event.Type).Inc()
+		return
+	}
+
+	select {
+	case e.bus <- event:
+		metrics.EventsPublished.WithLabelValues(event.Type).Inc()
+	default:
+		metrics.EventsDropped.WithLabelValues(event.Type).Inc()
+	}
 }
 
 func (e *EventBus) Subscribe() <-chan Event {
 	return e.bus
 }
+
+// Закрывает очередь: подписчики дочитывают накопленные события и завершаются,
+// а события, опубликованные после закрытия, отбрасываются
+func (e *EventBus) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.closed {
+		e.closed = true
+		close(e.bus)
+	}
+}
+
+func (e *EventBus) Len() int {
+	return len(e.bus)
+}
@@ -0,0 +1,65 @@
+package event_test
+
+import (
+	"linkshortener/pkg/event"
+	"linkshortener/pkg/metrics"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEventBusDropsWhenFull(t *testing.T) {
+	bus := event.NewEventBus()
+	dropped := metrics.EventsDropped.WithLabelValues("test.event")
+	before := testutil.ToFloat64(dropped)
+
+	for i := 0; i < 1025; i++ {
+		bus.Publish(event.Event{Type: "test.event", Data: i})
+	}
+
+	if bus.Len() != 1024 {
+		t.Fatalf("Expected queue depth 1024, got %d", bus.Len())
+	}
+
+	if got := testutil.ToFloat64(dropped) - before; got != 1 {
+		t.Fatalf("Expected 1 dropped event, got %v", got)
+	}
+
+	msg := <-bus.Subscribe()
+	if msg.Data != 0 {
+		t.Fatalf("Expected first event to be delivered first, got %v", msg.Data)
+	}
+}
+
+func TestEventBusCloseDrainsQueue(t *testing.T) {
+	bus := event.NewEventBus()
+	bus.Publish(event.Event{Type: "test.event", Data: 1})
+	bus.Close()
+	bus.Publish(event.Event{Type: "test.event", Data: 2})
+
+	var received []any
+	for msg := range bus.Subscribe() {
+		received = append(received, msg.Data)
+	}
+
+	if len(received) != 1 || received[0] != 1 {
+		t.Fatalf("Expected only the event published before close, got %v", received)
+	}
+}
+
+func TestEventBusCallsListeners(t *testing.T) {
+	bus := event.NewEventBus()
+	var received []any
+	bus.On(event.LinkUpdated, func(evt event.Event) {
+		received = append(received, evt.Data)
+	})
+
+	bus.Publish(event.Event{Type: event.LinkUpdated, Data: 1})
+	bus.Publish(event.Event{Type: event.LinkClicked, Data: 2})
+	bus.Close()
+	bus.Publish(event.Event{Type: event.LinkUpdated, Data: 3})
+
+	if len(received) != 2 || received[0] != 1 || received[1] != 3 {
+		t.Fatalf("Expected listener to get only link.updated events, got %v", received)
+	}
+}
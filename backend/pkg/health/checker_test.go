@@ -0,0 +1,90 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"linkshortener/pkg/health"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newHealthServer(checker *health.Checker) *http.ServeMux {
+	router := http.NewServeMux()
+	health.NewHealthHandler(router, &health.HealthHandlerDeps{Checker: checker})
+	return router
+}
+
+func getReport(t *testing.T, router *http.ServeMux) (int, health.Report) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, report
+}
+
+func TestReadyAllChecksPass(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Add("database", func(ctx context.Context) error { return nil })
+
+	status, report := getReport(t, newHealthServer(checker))
+
+	if status != http.StatusOK || report.Status != health.StatusOK {
+		t.Fatalf("Expected ready, got %d %s", status, report.Status)
+	}
+
+	if report.Checks["database"].Status != health.StatusOK {
+		t.Fatalf("Expected database check ok, got %+v", report.Checks["database"])
+	}
+}
+
+func TestReadyFailedAndTimedOutChecks(t *testing.T) {
+	checker := health.NewChecker(50 * time.Millisecond)
+	checker.Add("database", func(ctx context.Context) error { return errors.New("connection refused") })
+	checker.Add("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	startTime := time.Now()
+	status, report := getReport(t, newHealthServer(checker))
+
+	if time.Since(startTime) > 500*time.Millisecond {
+		t.Fatal("Expected slow check to be cut by timeout")
+	}
+
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status ServiceUnavailable, got %d", status)
+	}
+
+	if report.Checks["database"].Error != "connection refused" {
+		t.Fatalf("Expected database error detail, got %+v", report.Checks["database"])
+	}
+
+	if report.Checks["slow"].Status != health.StatusUnavailable {
+		t.Fatalf("Expected slow check to fail, got %+v", report.Checks["slow"])
+	}
+}
+
+func TestReadyFalseDuringShutdown(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	router := newHealthServer(checker)
+	checker.SetShuttingDown()
+
+	status, report := getReport(t, router)
+
+	if status != http.StatusServiceUnavailable || report.Status != health.StatusShutdown {
+		t.Fatalf("Expected shutting down, got %d %s", status, report.Status)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected liveness to stay OK, got %d", w.Code)
+	}
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"linkshortener/pkg/res"
+	"net/http"
+)
+
+type HealthHandlerDeps struct {
+	Checker *Checker
+}
+
+type HealthHandler struct {
+	deps *HealthHandlerDeps
+}
+
+func NewHealthHandler(router *http.ServeMux, deps *HealthHandlerDeps) {
+	healthHandler := &HealthHandler{
+		deps: deps,
+	}
+	router.HandleFunc("GET /healthz", healthHandler.Live())
+	router.HandleFunc("GET /readyz", healthHandler.Ready())
+}
+
+func (handler *HealthHandler) Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res.Response(w, http.StatusOK, map[string]string{"status": StatusOK})
+	}
+}
+
+func (handler *HealthHandler) Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := handler.deps.Checker.Ready(r.Context())
+
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		res.Response(w, status, report)
+	}
+}
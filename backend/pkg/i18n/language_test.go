@@ -0,0 +1,26 @@
+package i18n_test
+
+import (
+	"linkshortener/pkg/i18n"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: i18n.English},
+		{header: "ru-RU,ru;q=0.9,en;q=0.8", expected: i18n.Russian},
+		{header: "en-US,en;q=0.9,ru;q=0.8", expected: i18n.English},
+		{header: "de-DE,ru;q=0.5", expected: i18n.Russian},
+		{header: "fr", expected: i18n.English},
+		{header: "not a language;;", expected: i18n.English},
+	}
+
+	for _, testCase := range testCases {
+		if got := i18n.Negotiate(testCase.header); got != testCase.expected {
+			t.Errorf("Negotiate(%q) = %q, expected %q", testCase.header, got, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"linkshortener/pkg/logger"
+	"strings"
+	"testing"
+)
+
+func TestLoggerAddsRequestID(t *testing.T) {
+	var buffer bytes.Buffer
+	log := logger.New(&buffer, logger.FormatJSON, "info")
+
+	ctx := logger.WithRequestID(context.Background(), "req-123")
+	log.With("component", "test").InfoContext(ctx, "hello")
+
+	var record map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("Expected JSON output, got %s", buffer.String())
+	}
+
+	if record["request_id"] != "req-123" {
+		t.Fatalf("Expected request_id req-123, got %v", record["request_id"])
+	}
+
+	if record["component"] != "test" {
+		t.Fatalf("Expected component attribute, got %v", record["component"])
+	}
+}
+
+func TestLoggerTextFormatAndLevel(t *testing.T) {
+	var buffer bytes.Buffer
+	log := logger.New(&buffer, logger.FormatText, "warn")
+
+	log.Info("skipped")
+	log.Warn("written")
+
+	output := buffer.String()
+	if strings.Contains(output, "skipped") {
+		t.Fatalf("Expected info record to be filtered, got %s", output)
+	}
+
+	if !strings.Contains(output, "msg=written") {
+		t.Fatalf("Expected text record, got %s", output)
+	}
+}
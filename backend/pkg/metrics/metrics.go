@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "linkshortener"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route pattern and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	Panics = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_panics_total",
+		Help:      "Number of panics recovered in HTTP handlers by route pattern.",
+	}, []string{"route"})
+
+	Redirects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Number of short link redirects by result.",
+	}, []string{"result"})
+
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Number of cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	CodePoolMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "code_pool_misses_total",
+		Help:      "Number of short codes generated without a check because the code pool was empty.",
+	})
+
+	EventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_bus_published_total",
+		Help:      "Number of events accepted by the event bus.",
+	}, []string{"type"})
+
+	EventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_bus_dropped_total",
+		Help:      "Number of events dropped because the event bus queue was full.",
+	}, []string{"type"})
+
+	ClickFlushBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "click_flush_batch_size",
+		Help:      "Number of clicks written to the database per flush.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	})
+
+	ClickFlushDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "click_flush_duration_seconds",
+		Help:      "Time spent writing a batch of clicks to the database.",
+		Buckets:   prometheus.DefBuckets,
+	})
+)
+
+func RegisterEventBusDepth(depth func() int) {
+	register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "event_bus_queue_depth",
+		Help:      "Number of events waiting in the event bus queue.",
+	}, func() float64 {
+		return float64(depth())
+	}))
+}
+
+func RegisterDBStats(db *sql.DB) {
+	register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Повторная инициализация приложения (например, в тестах) заменяет ранее зарегистрированный коллектор
+func register(collector prometheus.Collector) {
+	prometheus.Unregister(collector)
+	prometheus.MustRegister(collector)
+}
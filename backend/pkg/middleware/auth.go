@@ -3,54 +3,127 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"linkshortener/config"
+	"linkshortener/internal/user"
+	"linkshortener/pkg/db"
+	"linkshortener/pkg/di"
 	"linkshortener/pkg/jwt"
+	"linkshortener/pkg/res"
+	"linkshortener/pkg/session"
 )
 
-func writeUnauthorized(w http.ResponseWriter) {
-	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+type key string
+
+const (
+	ContextEmailKey key = "userEmail"
+	ContextUserKey  key = "user"
+)
+
+func writeUnauthorized(w http.ResponseWriter, r *http.Request) {
+	res.Error(w, r, http.StatusUnauthorized, res.CodeUnauthorized)
 }
 
-func IsAuthenticated(next http.Handler, config *config.Config) http.Handler {
+func IsAuthenticated(next http.Handler, config *config.Config, userRepository di.IUserRepository) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := r.Header.Get("Authorization")
+		fromCookie := false
+		if token == "" && config.Auth.Cookie.Enabled {
+			if cookie, err := r.Cookie(session.AccessTokenCookie); err == nil {
+				token = "Bearer " + cookie.Value
+				fromCookie = true
+			}
+		}
 		if token == "" {
-			writeUnauthorized(w)
+			writeUnauthorized(w, r)
 			return
 		}
 		if !strings.HasPrefix(token, "Bearer ") {
-			writeUnauthorized(w)
+			writeUnauthorized(w, r)
 			return
 		}
 
 		token = strings.TrimPrefix(token, "Bearer ")
 		jwtService := jwt.NewJWT(config.Auth.SecretKey, config.Auth.RefreshTokenSecretKey)
 
-		user, err := jwtService.ValidateToken(token)
-		if err != nil {
+		email := ""
+		if fromCookie && !session.IsSafeMethod(r.Method) && !session.ValidCSRF(r) {
+			res.Error(w, r, http.StatusForbidden, res.CodeInvalidCSRFToken)
+			return
+		}
+
+		tokenUser, err := jwtService.ValidateToken(token)
+		if err != nil && fromCookie {
+			writeUnauthorized(w, r)
+			return
+		}
+		refreshed := err != nil
+		if refreshed {
 			refreshToken := r.Header.Get("X-Refresh-Token")
 			if refreshToken == "" {
-				writeUnauthorized(w)
+				writeUnauthorized(w, r)
 				return
 			}
 
-			newUser, newAccessToken, newRefreshToken, refreshErr := jwtService.RefreshTokens(refreshToken)
+			refreshUser, refreshErr := jwtService.ValidateRefreshToken(refreshToken)
 			if refreshErr != nil {
-				writeUnauthorized(w)
+				writeUnauthorized(w, r)
 				return
 			}
+			email = refreshUser.Email
+		} else {
+			email = tokenUser.Email
+		}
 
+		// Токены отключённых и удалённых пользователей отклоняются
+		currentUser, err := userRepository.FindByEmail(r.Context(), email)
+		if err != nil {
+			res.InternalError(w, r, err)
+			return
+		}
+		if currentUser == nil || currentUser.Disabled {
+			writeUnauthorized(w, r)
+			return
+		}
+
+		// Новая пара выдаётся только после проверки учётной записи
+		if refreshed {
+			newAccessToken, newRefreshToken, err := jwtService.CreateTokenPair(currentUser)
+			if err != nil {
+				res.InternalError(w, r, err)
+				return
+			}
 			w.Header().Set("X-New-Access-Token", newAccessToken)
 			w.Header().Set("X-New-Refresh-Token", newRefreshToken)
-
-			ctx := context.WithValue(r.Context(), "userEmail", newUser.Email)
-			next.ServeHTTP(w, r.WithContext(ctx))
-			return
 		}
 
-		ctx := context.WithValue(r.Context(), "userEmail", user.Email)
+		setLogUser(r.Context(), currentUser.ID)
+		// Сессия для read-your-writes: после записи чтения этого пользователя идут на основную БД
+		ctx := db.WithSession(WithUser(r.Context(), currentUser), strconv.FormatUint(uint64(currentUser.ID), 10))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+func IsAdmin(next http.Handler, config *config.Config, userRepository di.IUserRepository) http.Handler {
+	adminOnly := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		currentUser, ok := CurrentUser(r.Context())
+		if !ok || !currentUser.IsAdmin() {
+			res.Error(w, r, http.StatusForbidden, res.CodeForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+	return IsAuthenticated(adminOnly, config, userRepository)
+}
+
+func WithUser(ctx context.Context, currentUser *user.User) context.Context {
+	ctx = context.WithValue(ctx, ContextEmailKey, currentUser.Email)
+	return context.WithValue(ctx, ContextUserKey, currentUser)
+}
+
+func CurrentUser(ctx context.Context) (*user.User, bool) {
+	currentUser, ok := ctx.Value(ContextUserKey).(*user.User)
+	return currentUser, ok
+}
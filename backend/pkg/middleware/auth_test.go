@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"linkshortener/config"
+	"linkshortener/internal/user"
+	"linkshortener/pkg/jwt"
+	"linkshortener/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Просроченный access-токен и действующий refresh-токен пользователя
+func refreshRequest(t *testing.T, cfg *config.Config, u *user.User) *http.Request {
+	t.Helper()
+	refreshToken, err := jwt.NewJWT(cfg.Auth.SecretKey, cfg.Auth.RefreshTokenSecretKey).CreateRefreshToken(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/link", nil)
+	r.Header.Set("Authorization", "Bearer expired")
+	r.Header.Set("X-Refresh-Token", refreshToken)
+	return r
+}
+
+func TestIsAuthenticatedRefreshesTokens(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.SecretKey = "secret"
+	cfg.Auth.RefreshTokenSecretKey = "refresh"
+
+	users := user.NewMemoryUserRepository()
+	active, _ := users.Create(t.Context(), user.NewUser("active@example.com", "hash", "Active"))
+	disabled, _ := users.Create(t.Context(), user.NewUser("disabled@example.com", "hash", "Disabled"))
+	if _, err := users.SetDisabled(t.Context(), disabled.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := middleware.IsAuthenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), cfg, users)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, refreshRequest(t, cfg, active))
+	if w.Code != http.StatusOK || w.Header().Get("X-New-Access-Token") == "" || w.Header().Get("X-New-Refresh-Token") == "" {
+		t.Fatalf("expected 200 with new tokens, got %d %v", w.Code, w.Header())
+	}
+
+	// Отключённый пользователь не получает новую пару токенов вместе с 401
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, refreshRequest(t, cfg, disabled))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for disabled user, got %d", w.Code)
+	}
+	if w.Header().Get("X-New-Access-Token") != "" || w.Header().Get("X-New-Refresh-Token") != "" {
+		t.Fatalf("expected no tokens for disabled user, got %v", w.Header())
+	}
+}
@@ -4,13 +4,24 @@ import "net/http"
 
 type WrapperWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	bytesWritten int
+	wroteHeader  bool
 }
 
 func (w *WrapperWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 	w.statusCode = statusCode
+	w.wroteHeader = true
 }
 
+func (w *WrapperWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(data)
+	w.bytesWritten += n
+	return n, err
+}
 
-
+func (w *WrapperWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
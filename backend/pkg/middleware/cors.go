@@ -1,52 +1,146 @@
 package middleware
 
 import (
+	"linkshortener/config"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 )
 
-var allowedOrigins = []string{
-	"http://localhost:3000",
-	"http://localhost:8080",
-	"https://yourdomain.com",
+type CorsPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           int
+	AllowCredentials bool
+	AllowLocalhost   bool
 }
 
-func Cors(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
+// Переопределение политики для маршрутов, совпадающих по методу и префиксу пути
+type CorsRoute struct {
+	Methods    []string
+	PathPrefix string
+	Policy     *CorsPolicy
+}
+
+func NewCorsPolicy(config *config.Config) *CorsPolicy {
+	return &CorsPolicy{
+		AllowedOrigins:   config.Cors.AllowedOrigins,
+		AllowedMethods:   config.Cors.AllowedMethods,
+		AllowedHeaders:   config.Cors.AllowedHeaders,
+		ExposedHeaders:   config.Cors.ExposedHeaders,
+		MaxAge:           config.Cors.MaxAge,
+		AllowCredentials: config.Cors.AllowCredentials,
+		AllowLocalhost:   !config.IsProduction(),
+	}
+}
+
+// Публичный редирект открыт для любых источников, но без передачи учётных данных
+func NewRedirectCorsRoute(config *config.Config) CorsRoute {
+	return CorsRoute{
+		Methods:    []string{http.MethodGet, http.MethodHead},
+		PathPrefix: "/link/",
+		Policy: &CorsPolicy{
+			AllowedOrigins: config.Cors.RedirectAllowedOrigins,
+			AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+			MaxAge:         config.Cors.MaxAge,
+			AllowLocalhost: !config.IsProduction(),
+		},
+	}
+}
+
+func Cors(policy *CorsPolicy, routes ...CorsRoute) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			routePolicy := policyFor(r, policy, routes)
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			w.Header().Add("Vary", "Origin")
+			if routePolicy.isOriginAllowed(origin) {
+				if routePolicy.allowsAnyOrigin() && !routePolicy.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+				if routePolicy.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+				if !preflight && len(routePolicy.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(routePolicy.ExposedHeaders, ", "))
+				}
+			}
+
+			if preflight {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(routePolicy.AllowedMethods, ", "))
+				if len(routePolicy.AllowedHeaders) > 0 {
+					w.Header().Set("Access-Control-Allow-Headers", strings.Join(routePolicy.AllowedHeaders, ", "))
+				}
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(routePolicy.MaxAge))
+				w.WriteHeader(http.StatusOK)
+				return
+			}
 
-		if origin == "" {
 			next.ServeHTTP(w, r)
-			return
-		}
+		})
+	}
+}
 
-		if isOriginAllowed(origin) {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-		}
+func policyFor(r *http.Request, policy *CorsPolicy, routes []CorsRoute) *CorsPolicy {
+	method := r.Method
+	if method == http.MethodOptions {
+		method = r.Header.Get("Access-Control-Request-Method")
+	}
 
-		if r.Method == "OPTIONS" {
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-			w.Header().Set("Access-Control-Max-Age", "86400")
-			w.WriteHeader(http.StatusOK)
-			return
+	for _, route := range routes {
+		if strings.HasPrefix(r.URL.Path, route.PathPrefix) && slices.Contains(route.Methods, method) {
+			return route.Policy
 		}
+	}
+	return policy
+}
 
-		next.ServeHTTP(w, r)
-	})
+func (policy *CorsPolicy) allowsAnyOrigin() bool {
+	return slices.Contains(policy.AllowedOrigins, "*")
 }
 
-func isOriginAllowed(origin string) bool {
-	if strings.HasPrefix(origin, "http://localhost:") || strings.HasPrefix(origin, "https://localhost:") {
-		return true
+func (policy *CorsPolicy) isOriginAllowed(origin string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
 	}
 
-	for _, allowed := range allowedOrigins {
-		if origin == allowed {
+	if isLocalhost(parsed.Hostname()) {
+		return policy.AllowLocalhost
+	}
+
+	for _, allowed := range policy.AllowedOrigins {
+		if allowed == "*" || allowed == origin || matchWildcard(allowed, parsed) {
 			return true
 		}
 	}
 
 	return false
 }
+
+// Шаблон вида https://*.example.com совпадает с любым поддоменом, но не с самим example.com
+func matchWildcard(pattern string, origin *url.URL) bool {
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok || scheme != origin.Scheme {
+		return false
+	}
+	return strings.HasSuffix(origin.Host, "."+host)
+}
+
+func isLocalhost(hostname string) bool {
+	return hostname == "localhost" || hostname == "127.0.0.1" || hostname == "::1"
+}
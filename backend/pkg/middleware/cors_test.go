@@ -0,0 +1,120 @@
+package middleware_test
+
+import (
+	"linkshortener/config"
+	"linkshortener/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newCorsHandler(env string) http.Handler {
+	cfg := &config.Config{
+		Env: env,
+		Cors: config.CorsConfig{
+			AllowedOrigins:         []string{"https://app.example.com", "https://*.example.org"},
+			AllowedMethods:         []string{"GET", "POST"},
+			AllowedHeaders:         []string{"Content-Type", "Authorization"},
+			ExposedHeaders:         []string{"X-New-Access-Token"},
+			MaxAge:                 600,
+			AllowCredentials:       true,
+			RedirectAllowedOrigins: []string{"*"},
+		},
+	}
+	cors := middleware.Cors(middleware.NewCorsPolicy(cfg), middleware.NewRedirectCorsRoute(cfg))
+	return cors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func TestCorsAllowedOrigins(t *testing.T) {
+	testCases := []struct {
+		env      string
+		origin   string
+		expected string
+	}{
+		{env: "development", origin: "https://app.example.com", expected: "https://app.example.com"},
+		{env: "development", origin: "https://a.b.example.org", expected: "https://a.b.example.org"},
+		{env: "development", origin: "https://example.org", expected: ""},
+		{env: "development", origin: "http://app.example.com", expected: ""},
+		{env: "development", origin: "https://yourdomain.com", expected: ""},
+		{env: "development", origin: "http://localhost:5173", expected: "http://localhost:5173"},
+		{env: config.EnvProduction, origin: "http://localhost:5173", expected: ""},
+		{env: config.EnvProduction, origin: "https://app.example.com", expected: "https://app.example.com"},
+	}
+
+	for _, testCase := range testCases {
+		r := httptest.NewRequest(http.MethodPost, "/link", nil)
+		r.Header.Set("Origin", testCase.origin)
+		w := httptest.NewRecorder()
+
+		newCorsHandler(testCase.env).ServeHTTP(w, r)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != testCase.expected {
+			t.Fatalf("%s %s: expected allow origin %q, got %q", testCase.env, testCase.origin, testCase.expected, got)
+		}
+	}
+}
+
+func TestCorsExposedHeaders(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/link", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+
+	newCorsHandler("development").ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-New-Access-Token" {
+		t.Fatalf("expected exposed headers, got %q", got)
+	}
+
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("expected credentials to be allowed, got %q", got)
+	}
+}
+
+func TestCorsPreflight(t *testing.T) {
+	r := httptest.NewRequest(http.MethodOptions, "/link", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+
+	newCorsHandler("development").ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %d", w.Code)
+	}
+
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Fatalf("expected allowed methods, got %q", got)
+	}
+
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("expected max age 600, got %q", got)
+	}
+}
+
+func TestCorsRedirectOverride(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/link/abc123", nil)
+	r.Header.Set("Origin", "https://any.site")
+	w := httptest.NewRecorder()
+
+	newCorsHandler(config.EnvProduction).ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected wildcard origin, got %q", got)
+	}
+
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("expected no credentials, got %q", got)
+	}
+
+	r = httptest.NewRequest(http.MethodDelete, "/link/12", nil)
+	r.Header.Set("Origin", "https://any.site")
+	w = httptest.NewRecorder()
+
+	newCorsHandler(config.EnvProduction).ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected origin to be rejected, got %q", got)
+	}
+}
@@ -1,11 +1,21 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"time"
 )
 
+type logKey string
+
+const requestLogKey logKey = "requestLog"
+
+// Данные, которые становятся известны только внутри обработчика (например, пользователь)
+type requestLog struct {
+	userID uint
+}
+
 func LogRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
@@ -13,8 +23,30 @@ func LogRequest(next http.Handler) http.Handler {
 			ResponseWriter: w,
 			statusCode:     http.StatusOK,
 		}
+		entry := &requestLog{}
+		r = withRoute(r)
+		r = r.WithContext(context.WithValue(r.Context(), requestLogKey, entry))
+
 		next.ServeHTTP(wrapper, r)
-		duration := time.Since(startTime)
-		log.Printf("\nRequest: %d %s %s %s %s", wrapper.statusCode, r.Method, r.URL.Path, r.RemoteAddr, duration)
+
+		attrs := []any{
+			slog.Int("status", wrapper.statusCode),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", routePattern(r)),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.Int("bytes", wrapper.bytesWritten),
+			slog.Duration("duration", time.Since(startTime)),
+		}
+		if entry.userID != 0 {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(entry.userID)))
+		}
+		slog.InfoContext(r.Context(), "request", attrs...)
 	})
 }
+
+func setLogUser(ctx context.Context, userID uint) {
+	if entry, ok := ctx.Value(requestLogKey).(*requestLog); ok {
+		entry.userID = userID
+	}
+}
@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"linkshortener/pkg/logger"
+	"linkshortener/pkg/middleware"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDHonoursIncomingHeader(t *testing.T) {
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if logger.RequestID(r.Context()) != "incoming-id" {
+			t.Fatalf("expected request id in context, got %q", logger.RequestID(r.Context()))
+		}
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(middleware.RequestIDHeader, "incoming-id")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Header().Get(middleware.RequestIDHeader) != "incoming-id" {
+		t.Fatalf("expected request id to be echoed, got %q", w.Header().Get(middleware.RequestIDHeader))
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(middleware.RequestIDHeader, "bad id\n")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if requestID := w.Header().Get(middleware.RequestIDHeader); len(requestID) != 32 {
+		t.Fatalf("expected generated request id, got %q", requestID)
+	}
+}
+
+func TestLogRequest(t *testing.T) {
+	var buffer bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(logger.New(&buffer, logger.FormatJSON, "info"))
+	defer slog.SetDefault(defaultLogger)
+
+	router := http.NewServeMux()
+	router.HandleFunc("GET /link/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	})
+	handler := middleware.Chain(middleware.RequestID, middleware.LogRequest)(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/link/abc", nil)
+	r.Header.Set(middleware.RequestIDHeader, "log-id")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var record map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("expected JSON log line, got %s", buffer.String())
+	}
+
+	expected := map[string]any{
+		"request_id": "log-id",
+		"route":      "GET /link/{hash}",
+		"status":     float64(http.StatusTeapot),
+		"bytes":      float64(5),
+	}
+	for name, value := range expected {
+		if record[name] != value {
+			t.Fatalf("expected %s=%v, got %v", name, value, record[name])
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"linkshortener/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		wrapper := &WrapperWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		r = withRoute(r)
+		next.ServeHTTP(wrapper, r)
+
+		// ServeMux записывает шаблон маршрута в r.Pattern; несовпавшие запросы объединяются, чтобы произвольные пути не раздували число серий
+		route := routePattern(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(wrapper.statusCode)
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(startTime).Seconds())
+	})
+}
@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"linkshortener/pkg/metrics"
+	"linkshortener/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsRouteLabels(t *testing.T) {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /link/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+	handler := middleware.Metrics(router)
+
+	matched := metrics.HTTPRequests.WithLabelValues("GET", "GET /link/{hash}", "307")
+	unmatched := metrics.HTTPRequests.WithLabelValues("GET", "unmatched", "404")
+	matchedBefore := testutil.ToFloat64(matched)
+	unmatchedBefore := testutil.ToFloat64(unmatched)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/link/abc", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/link/def", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	if got := testutil.ToFloat64(matched) - matchedBefore; got != 2 {
+		t.Fatalf("expected 2 requests for route pattern, got %v", got)
+	}
+
+	if got := testutil.ToFloat64(unmatched) - unmatchedBefore; got != 1 {
+		t.Fatalf("expected 1 unmatched request, got %v", got)
+	}
+}
+
+func TestMetricsRouteLabelsBehindTracing(t *testing.T) {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /stats/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := middleware.Chain(middleware.Metrics, middleware.Tracing)(middleware.Route(router))
+
+	matched := metrics.HTTPRequests.WithLabelValues("GET", "GET /stats/{id}", "200")
+	before := testutil.ToFloat64(matched)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stats/1", nil))
+
+	if got := testutil.ToFloat64(matched) - before; got != 1 {
+		t.Fatalf("expected route pattern to reach outer middleware, got %v", got)
+	}
+}
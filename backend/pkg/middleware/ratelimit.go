@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"linkshortener/config"
+	"linkshortener/pkg/jwt"
+	"linkshortener/pkg/ratelimit"
+	"linkshortener/pkg/res"
+	"linkshortener/pkg/session"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func RateLimit(store ratelimit.Store, policies []config.RateLimitPolicy, jwtService *jwt.JWT) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			policy, ok := matchRateLimitPolicy(r, policies)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := policy.Method + " " + policy.PathPrefix + "|" + rateLimitKey(r, policy.Key, jwtService)
+			result, err := store.Take(r.Context(), key, ratelimit.Limit{
+				Requests: policy.Requests,
+				Period:   policy.Period,
+				Burst:    policy.Burst,
+			})
+			if err != nil {
+				slog.ErrorContext(r.Context(), "rate limit store error", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(result.Reset))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", seconds(result.RetryAfter))
+				res.Error(w, r, http.StatusTooManyRequests, res.CodeRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func matchRateLimitPolicy(r *http.Request, policies []config.RateLimitPolicy) (config.RateLimitPolicy, bool) {
+	for _, policy := range policies {
+		if (policy.Method == "*" || policy.Method == r.Method) && strings.HasPrefix(r.URL.Path, policy.PathPrefix) {
+			return policy, true
+		}
+	}
+	return config.RateLimitPolicy{}, false
+}
+
+// Ключ пользователя или API-ключа при их отсутствии сводится к IP клиента
+func rateLimitKey(r *http.Request, keyType string, jwtService *jwt.JWT) string {
+	switch keyType {
+	case config.RateLimitKeyUser:
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			if cookie, err := r.Cookie(session.AccessTokenCookie); err == nil {
+				token = cookie.Value
+			}
+		}
+		if token != "" {
+			if tokenUser, err := jwtService.ValidateToken(token); err == nil {
+				return "user:" + tokenUser.Email
+			}
+		}
+	case config.RateLimitKeyAPIKey:
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			hash := sha256.Sum256([]byte(apiKey))
+			return "api_key:" + hex.EncodeToString(hash[:])
+		}
+	}
+	return "ip:" + remoteHost(r.RemoteAddr)
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
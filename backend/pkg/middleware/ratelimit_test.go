@@ -0,0 +1,95 @@
+package middleware_test
+
+import (
+	"linkshortener/config"
+	"linkshortener/pkg/jwt"
+	"linkshortener/pkg/middleware"
+	"linkshortener/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newRateLimitHandler() http.Handler {
+	policies := []config.RateLimitPolicy{{
+		Method:     http.MethodPost,
+		PathPrefix: "/auth/login",
+		Key:        config.RateLimitKeyIP,
+		Requests:   1,
+		Period:     time.Minute,
+		Burst:      2,
+	}}
+	stack := middleware.Chain(
+		middleware.RealIP([]string{"10.0.0.0/8"}),
+		middleware.RateLimit(ratelimit.NewMemoryStore(), policies, jwt.NewJWT("secret", "refresh")),
+	)
+	return stack(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func loginFrom(handler http.Handler, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	r.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	handler := newRateLimitHandler()
+
+	w := loginFrom(handler, "203.0.113.1:1234", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %d", w.Code)
+	}
+	if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("unexpected rate limit headers: %v", w.Header())
+	}
+
+	loginFrom(handler, "203.0.113.1:1234", "")
+	w = loginFrom(handler, "203.0.113.1:1234", "")
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status TooManyRequests, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After header")
+	}
+}
+
+func TestRateLimitSkipsOtherRoutes(t *testing.T) {
+	handler := newRateLimitHandler()
+
+	for i := 0; i < 5; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/link", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status OK, got %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimitTrustedProxy(t *testing.T) {
+	handler := newRateLimitHandler()
+
+	loginFrom(handler, "10.0.0.2:1234", "198.51.100.1")
+	loginFrom(handler, "10.0.0.2:1234", "198.51.100.1")
+
+	// Другой клиент за тем же прокси имеет собственный лимит
+	if w := loginFrom(handler, "10.0.0.2:1234", "198.51.100.2"); w.Code != http.StatusOK {
+		t.Fatalf("expected status OK for another client, got %d", w.Code)
+	}
+
+	// Подделанный X-Forwarded-For от недоверенного адреса игнорируется
+	loginFrom(handler, "203.0.113.9:1234", "198.51.100.3")
+	loginFrom(handler, "203.0.113.9:1234", "198.51.100.4")
+	if w := loginFrom(handler, "203.0.113.9:1234", "198.51.100.5"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status TooManyRequests for spoofed header, got %d", w.Code)
+	}
+}
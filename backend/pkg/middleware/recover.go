@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"linkshortener/pkg/metrics"
+	"linkshortener/pkg/res"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Внешний сервис учёта ошибок (Sentry и т.п.)
+type ErrorReporter interface {
+	Report(ctx context.Context, err error, stack []byte)
+}
+
+func Recover(reporter ErrorReporter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapper := &WrapperWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+			r = withRoute(r)
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// Штатный способ оборвать ответ, его обрабатывает сам http.Server
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				err, ok := recovered.(error)
+				if !ok {
+					err = fmt.Errorf("%v", recovered)
+				}
+				stack := debug.Stack()
+				route := routePattern(r)
+				if route == "" {
+					route = "unmatched"
+				}
+
+				slog.ErrorContext(r.Context(), "panic recovered",
+					slog.String("error", err.Error()),
+					slog.String("route", route),
+					slog.String("stack", string(stack)),
+				)
+				metrics.Panics.WithLabelValues(route).Inc()
+
+				span := trace.SpanFromContext(r.Context())
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "panic")
+
+				if reporter != nil {
+					reporter.Report(r.Context(), err, stack)
+				}
+
+				// Если ответ уже начат, остаётся только оборвать его
+				if wrapper.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				res.Error(wrapper, r, http.StatusInternalServerError, res.CodeInternal)
+			}()
+
+			next.ServeHTTP(wrapper, r)
+		})
+	}
+}
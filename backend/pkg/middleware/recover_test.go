@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"linkshortener/pkg/metrics"
+	"linkshortener/pkg/middleware"
+	"linkshortener/pkg/res"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type MockErrorReporter struct {
+	errors []error
+}
+
+func (m *MockErrorReporter) Report(ctx context.Context, err error, stack []byte) {
+	m.errors = append(m.errors, err)
+}
+
+func TestRecoverReturnsJSONError(t *testing.T) {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	reporter := &MockErrorReporter{}
+	handler := middleware.Chain(middleware.RequestID, middleware.Recover(reporter))(middleware.Route(router))
+
+	panics := metrics.Panics.WithLabelValues("GET /panic")
+	before := testutil.ToFloat64(panics)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(middleware.RequestIDHeader, "test-request")
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+
+	var body res.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if body.Code != res.CodeInternal || body.RequestID != "test-request" {
+		t.Fatalf("expected internal error with request id, got %+v", body)
+	}
+
+	if got := testutil.ToFloat64(panics) - before; got != 1 {
+		t.Fatalf("expected panic metric to increase by 1, got %v", got)
+	}
+
+	if len(reporter.errors) != 1 || reporter.errors[0].Error() != "boom" {
+		t.Fatalf("expected reporter to receive the panic, got %v", reporter.errors)
+	}
+}
+
+func TestRecoverAbortsStartedResponse(t *testing.T) {
+	handler := middleware.Recover(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("boom")
+	}))
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Fatalf("expected http.ErrAbortHandler, got %v", recovered)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
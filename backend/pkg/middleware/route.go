@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type routeKey struct{}
+
+type route struct {
+	pattern string
+}
+
+// Middleware, которые создают копию запроса (WithContext), скрывают от внешних слоёв r.Pattern,
+// выставленный ServeMux. Поэтому шаблон маршрута дополнительно сохраняется в общем контексте.
+func withRoute(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(routeKey{}).(*route); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, &route{}))
+}
+
+func routePattern(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	if matched, ok := r.Context().Value(routeKey{}).(*route); ok {
+		return matched.pattern
+	}
+	return ""
+}
+
+// Оборачивает роутер и передаёт найденный им шаблон маршрута во внешние middleware
+func Route(router http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// defer, чтобы шаблон был известен и при панике в обработчике
+		defer func() {
+			if matched, ok := r.Context().Value(routeKey{}).(*route); ok {
+				matched.pattern = r.Pattern
+			}
+		}()
+		router.ServeHTTP(w, r)
+	})
+}
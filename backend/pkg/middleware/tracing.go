@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"linkshortener/pkg/tracing"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", r.RemoteAddr),
+			),
+		)
+		defer span.End()
+
+		wrapper := &WrapperWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		r = withRoute(r.WithContext(ctx))
+
+		next.ServeHTTP(wrapper, r)
+
+		// Имя спана уточняется шаблоном маршрута, который ServeMux записывает в r.Pattern
+		if pattern := routePattern(r); pattern != "" {
+			span.SetName(pattern)
+			span.SetAttributes(attribute.String("http.route", pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", wrapper.statusCode))
+		if wrapper.statusCode >= 500 {
+			span.SetStatus(codes.Error, strconv.Itoa(wrapper.statusCode))
+		}
+	})
+}
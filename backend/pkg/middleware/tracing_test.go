@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"context"
+	"linkshortener/pkg/event"
+	"linkshortener/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupTracing(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return recorder
+}
+
+func TestTracingPropagatesThroughEvents(t *testing.T) {
+	recorder := setupTracing(t)
+
+	var published event.Event
+	router := http.NewServeMux()
+	router.HandleFunc("GET /link/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		published = event.NewEvent(r.Context(), event.LinkClicked, uint(1))
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/link/abc", nil)
+	r.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	middleware.Tracing(router).ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected publish and server spans, got %d", len(spans))
+	}
+
+	server := spans[1]
+	if server.Name() != "GET /link/{hash}" {
+		t.Fatalf("expected span named by route pattern, got %q", server.Name())
+	}
+
+	if server.SpanContext().TraceID().String() != "0af7651916cd43dd8448eb211c80319c" {
+		t.Fatalf("expected incoming trace to be continued, got %s", server.SpanContext().TraceID())
+	}
+
+	consumer := trace.SpanContextFromContext(published.Context(context.Background()))
+	if consumer.TraceID() != server.SpanContext().TraceID() {
+		t.Fatal("expected event to carry the request trace")
+	}
+}
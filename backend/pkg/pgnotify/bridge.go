@@ -0,0 +1,262 @@
+package pgnotify
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"linkshortener/pkg/event"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Метка Event.Source у событий, полученных от других инстансов
+const Source = "postgres"
+
+const (
+	outgoingQueueSize = 256
+	notifyTimeout     = 5 * time.Second
+	minBackoff        = time.Second
+	maxBackoff        = 30 * time.Second
+)
+
+// Сообщение в канале NOTIFY; размер payload в Postgres ограничен 8000 байт
+type message struct {
+	Instance string            `json:"instance"`
+	Type     string            `json:"type"`
+	Data     json.RawMessage   `json:"data"`
+	Trace    map[string]string `json:"trace,omitempty"`
+}
+
+// Мост между шиной событий и каналом Postgres: выбранные события отправляются через NOTIFY
+// и публикуются в шину остальных инстансов, которые слушают канал через LISTEN.
+// После потери соединения мост переподключается и вызывает обработчики OnResync,
+// потому что пропущенные за это время уведомления Postgres не хранит
+type Bridge struct {
+	bus      *event.EventBus
+	db       *sql.DB
+	dsn      string
+	channel  string
+	instance string
+
+	mu       sync.RWMutex
+	decoders map[string]func(json.RawMessage) (any, error)
+	resync   []func()
+
+	outgoing  chan event.Event
+	connected atomic.Bool
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// db — пул основной БД для NOTIFY, dsn — для отдельного соединения под LISTEN
+func NewBridge(bus *event.EventBus, db *sql.DB, dsn, channel string) *Bridge {
+	return &Bridge{
+		bus:      bus,
+		db:       db,
+		dsn:      dsn,
+		channel:  channel,
+		instance: newInstanceID(),
+		decoders: make(map[string]func(json.RawMessage) (any, error)),
+		outgoing: make(chan event.Event, outgoingQueueSize),
+	}
+}
+
+// Пересылает события eventType между инстансами; T — тип Event.Data, он передаётся в JSON
+func Forward[T any](b *Bridge, eventType string) {
+	b.mu.Lock()
+	b.decoders[eventType] = func(raw json.RawMessage) (any, error) {
+		var data T
+		err := json.Unmarshal(raw, &data)
+		return data, err
+	}
+	b.mu.Unlock()
+
+	b.bus.On(eventType, b.enqueue)
+}
+
+func (b *Bridge) OnResync(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resync = append(b.resync, fn)
+}
+
+func (b *Bridge) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	b.wg.Add(2)
+	go func() {
+		defer b.wg.Done()
+		b.send(ctx)
+	}()
+	go func() {
+		defer b.wg.Done()
+		b.listen(ctx)
+	}()
+}
+
+func (b *Bridge) Connected() bool {
+	return b.connected.Load()
+}
+
+// Закрывает соединение LISTEN; неотправленные события отбрасываются
+func (b *Bridge) Close() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+	b.wg.Wait()
+}
+
+// Вызывается шиной синхронно, поэтому только ставит событие в очередь отправки
+func (b *Bridge) enqueue(evt event.Event) {
+	if evt.Source != "" {
+		return
+	}
+	select {
+	case b.outgoing <- evt:
+	default:
+		slog.Warn("event bridge queue is full, event dropped", "type", evt.Type)
+	}
+}
+
+func (b *Bridge) send(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt := <-b.outgoing:
+			if err := b.notify(ctx, evt); err != nil {
+				slog.Error("failed to send event notification", "type", evt.Type, "error", err)
+			}
+		}
+	}
+}
+
+func (b *Bridge) notify(ctx context.Context, evt event.Event) error {
+	payload, err := b.encode(evt)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	_, err = b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", b.channel, payload)
+	return err
+}
+
+func (b *Bridge) encode(evt event.Event) (string, error) {
+	data, err := json.Marshal(evt.Data)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(message{
+		Instance: b.instance,
+		Type:     evt.Type,
+		Data:     data,
+		Trace:    evt.Trace,
+	})
+	return string(payload), err
+}
+
+func (b *Bridge) listen(ctx context.Context) {
+	backoff := minBackoff
+	reconnect := false
+
+	for {
+		err := b.listenOnce(ctx, func() {
+			b.connected.Store(true)
+			backoff = minBackoff
+			if reconnect {
+				slog.Info("event bridge reconnected, resyncing")
+				b.runResync()
+			}
+			reconnect = true
+		})
+		b.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("event bridge connection lost", "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (b *Bridge) listenOnce(ctx context.Context, onConnected func()) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return err
+	}
+	onConnected()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		b.receive(notification.Payload)
+	}
+}
+
+func (b *Bridge) receive(payload string) {
+	var msg message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		slog.Error("bad event notification", "payload", payload, "error", err)
+		return
+	}
+	if msg.Instance == b.instance {
+		return
+	}
+
+	b.mu.RLock()
+	decode, ok := b.decoders[msg.Type]
+	b.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	data, err := decode(msg.Data)
+	if err != nil {
+		slog.Error("bad event notification data", "type", msg.Type, "error", err)
+		return
+	}
+	b.bus.Publish(event.Event{
+		Type:   msg.Type,
+		Data:   data,
+		Trace:  msg.Trace,
+		Source: Source,
+	})
+}
+
+func (b *Bridge) runResync() {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.resync {
+		fn()
+	}
+}
+
+func newInstanceID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		panic("failed to generate instance id: " + err.Error())
+	}
+	return hex.EncodeToString(id)
+}
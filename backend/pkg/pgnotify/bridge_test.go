@@ -0,0 +1,143 @@
+package pgnotify
+
+import (
+	"database/sql"
+	"linkshortener/pkg/event"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func TestBridgeDeliversEventsFromOtherInstances(t *testing.T) {
+	senderBus, receiverBus := event.NewEventBus(), event.NewEventBus()
+	sender := NewBridge(senderBus, nil, "", "events")
+	receiver := NewBridge(receiverBus, nil, "", "events")
+	Forward[event.LinkChanged](receiver, event.LinkUpdated)
+
+	var received []event.Event
+	receiverBus.On(event.LinkUpdated, func(evt event.Event) {
+		received = append(received, evt)
+	})
+
+	payload, err := sender.encode(event.Event{
+		Type: event.LinkUpdated,
+		Data: event.LinkChanged{ID: 1, Hashes: []string{"abc"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver.receive(payload)
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(received))
+	}
+	changed, ok := received[0].Data.(event.LinkChanged)
+	if !ok || changed.ID != 1 || len(changed.Hashes) != 1 || changed.Hashes[0] != "abc" {
+		t.Fatalf("unexpected event data: %#v", received[0].Data)
+	}
+	if received[0].Source != Source {
+		t.Fatalf("expected remote event to be marked, got source %q", received[0].Source)
+	}
+	// Полученное событие не отправляется обратно в канал
+	if len(receiver.outgoing) != 0 {
+		t.Fatalf("expected remote event not to be forwarded, got %d queued", len(receiver.outgoing))
+	}
+}
+
+func TestBridgeIgnoresOwnAndUnknownEvents(t *testing.T) {
+	bus := event.NewEventBus()
+	bridge := NewBridge(bus, nil, "", "events")
+	Forward[event.LinkChanged](bridge, event.LinkDeleted)
+
+	calls := 0
+	bus.On(event.LinkDeleted, func(event.Event) { calls++ })
+
+	own, err := bridge.encode(event.Event{Type: event.LinkDeleted, Data: event.LinkChanged{ID: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bridge.receive(own)
+
+	other := NewBridge(event.NewEventBus(), nil, "", "events")
+	unknown, err := other.encode(event.Event{Type: "link.unknown", Data: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bridge.receive(unknown)
+	bridge.receive("not json")
+
+	if calls != 0 {
+		t.Fatalf("expected no events, got %d", calls)
+	}
+}
+
+// Проверка с настоящим Postgres, если задан TEST_DB_URL
+func TestBridgePostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	channel := "linkshortener_events_test"
+	senderBus, receiverBus := event.NewEventBus(), event.NewEventBus()
+	sender := NewBridge(senderBus, db, dsn, channel)
+	receiver := NewBridge(receiverBus, db, dsn, channel)
+	Forward[event.LinkChanged](sender, event.LinkUpdated)
+	Forward[event.LinkChanged](receiver, event.LinkUpdated)
+
+	received := make(chan event.LinkChanged, 1)
+	receiverBus.On(event.LinkUpdated, func(evt event.Event) {
+		if evt.Source == Source {
+			received <- evt.Data.(event.LinkChanged)
+		}
+	})
+	resynced := make(chan struct{}, 1)
+	receiver.OnResync(func() { resynced <- struct{}{} })
+
+	sender.Start()
+	receiver.Start()
+	t.Cleanup(sender.Close)
+	t.Cleanup(receiver.Close)
+	waitConnected(t, sender, receiver)
+
+	senderBus.Publish(event.Event{Type: event.LinkUpdated, Data: event.LinkChanged{ID: 7, Hashes: []string{"abc"}}})
+	select {
+	case changed := <-received:
+		if changed.ID != 7 {
+			t.Fatalf("unexpected event: %+v", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	// Обрыв соединения LISTEN: мост переподключается и запускает resync
+	_, err = db.Exec("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE query = $1", `LISTEN "`+channel+`"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-resynced:
+	case <-time.After(10 * time.Second):
+		t.Fatal("bridge did not resync after reconnect")
+	}
+}
+
+func waitConnected(t *testing.T, bridges ...*Bridge) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, bridge := range bridges {
+		for !bridge.Connected() {
+			if time.Now().After(deadline) {
+				t.Fatal("bridge did not connect")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package ratelimit_test
+
+import (
+	"context"
+	"linkshortener/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		result, err := store.Take(context.Background(), "key", limit)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !result.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+		if result.Remaining != 1-i {
+			t.Fatalf("Expected remaining %d, got %d", 1-i, result.Remaining)
+		}
+	}
+
+	result, err := store.Take(context.Background(), "key", limit)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Allowed {
+		t.Fatal("Expected request to be limited")
+	}
+
+	if result.RetryAfter <= 0 || result.RetryAfter > time.Hour {
+		t.Fatalf("Expected retry after within an hour, got %s", result.RetryAfter)
+	}
+}
+
+func TestMemoryStoreSeparateKeys(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 1}
+
+	first, _ := store.Take(context.Background(), "first", limit)
+	second, _ := store.Take(context.Background(), "second", limit)
+
+	if !first.Allowed || !second.Allowed {
+		t.Fatal("Expected separate keys to have separate buckets")
+	}
+}
+
+func TestMemoryStoreRefill(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 100, Period: time.Second, Burst: 1}
+
+	store.Take(context.Background(), "key", limit)
+	time.Sleep(20 * time.Millisecond)
+
+	result, _ := store.Take(context.Background(), "key", limit)
+	if !result.Allowed {
+		t.Fatal("Expected bucket to be refilled")
+	}
+}
@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"linkshortener/pkg/db"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey;size:255"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;index"`
+}
+
+// Хранилище в Postgres разделяет лимиты между несколькими инстансами. Корзина, которую не трогали
+// дольше idleAfter, успела пополниться целиком и ничем не отличается от новой, поэтому Start
+// периодически удаляет такие строки, иначе таблица росла бы с каждым новым IP или пользователем
+type PostgresStore struct {
+	db        *db.Db
+	idleAfter time.Duration
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func NewPostgresStore(db *db.Db, idleAfter time.Duration) *PostgresStore {
+	return &PostgresStore{db: db, idleAfter: idleAfter}
+}
+
+// Запускает периодическую очистку неактивных корзин
+func (s *PostgresStore) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+					slog.WarnContext(ctx, "failed to sweep rate limit buckets", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (s *PostgresStore) Close() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Удаляет корзины, не тронутые дольше idleAfter, и возвращает их число
+func (s *PostgresStore) Sweep(ctx context.Context) (int64, error) {
+	tx, cancel := s.db.Query(ctx)
+	defer cancel()
+
+	result := tx.Where("updated_at < ?", time.Now().Add(-s.idleAfter)).Delete(&RateLimitBucket{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	var result Result
+
+	query, cancel := s.db.Query(ctx)
+	defer cancel()
+
+	err := query.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&RateLimitBucket{
+			Key:       key,
+			Tokens:    float64(limit.Burst),
+			UpdatedAt: now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var bucket RateLimitBucket
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bucket, "key = ?", key).Error
+		if err != nil {
+			return err
+		}
+
+		bucket.Tokens, result = take(bucket.Tokens, bucket.UpdatedAt, now, limit)
+		bucket.UpdatedAt = now
+		return tx.Save(&bucket).Error
+	})
+
+	return result, err
+}
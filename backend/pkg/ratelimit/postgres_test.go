@@ -0,0 +1,43 @@
+package ratelimit_test
+
+import (
+	"linkshortener/internal/storetest"
+	"linkshortener/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestPostgresStoreSweepDeletesIdleBuckets(t *testing.T) {
+	for _, database := range storetest.Databases() {
+		t.Run(database.Name, func(t *testing.T) {
+			db := database.New(t)
+			store := ratelimit.NewPostgresStore(db, time.Hour)
+			limit := ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 1}
+
+			if _, err := store.Take(t.Context(), "active", limit); err != nil {
+				t.Fatal(err)
+			}
+			stale := ratelimit.RateLimitBucket{Key: "idle", Tokens: 0, UpdatedAt: time.Now().Add(-2 * time.Hour)}
+			if err := db.Create(&stale).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			deleted, err := store.Sweep(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != 1 {
+				t.Fatalf("expected 1 idle bucket deleted, got %d", deleted)
+			}
+
+			// Активная корзина осталась, поэтому лимит по-прежнему исчерпан
+			result, err := store.Take(t.Context(), "active", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed {
+				t.Fatal("expected active bucket to survive the sweep")
+			}
+		})
+	}
+}
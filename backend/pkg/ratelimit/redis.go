@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisKeyPrefix = "linkshortener:ratelimit:"
+	// Сколько раз повторять списание, если корзину одновременно изменил другой запрос
+	redisMaxRetries = 10
+)
+
+var ErrContention = errors.New("rate limit bucket is too contended")
+
+// Хранилище в Redis разделяет лимиты между инстансами без нагрузки на основную БД.
+// Корзина — hash с полями tokens и updated_at, который удаляется, когда полностью пополнится
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Чтение и запись корзины выполняются в WATCH/MULTI: при конкурентном изменении попытка повторяется
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	bucketKey := redisKeyPrefix + key
+	var result Result
+
+	takeToken := func(tx *redis.Tx) error {
+		now := time.Now()
+		tokens, updatedAt := float64(limit.Burst), now
+
+		values, err := tx.HMGet(ctx, bucketKey, "tokens", "updated_at").Result()
+		if err != nil {
+			return err
+		}
+		if stored, ok := values[0].(string); ok {
+			if tokens, err = strconv.ParseFloat(stored, 64); err != nil {
+				return err
+			}
+		}
+		if stored, ok := values[1].(string); ok {
+			nanos, err := strconv.ParseInt(stored, 10, 64)
+			if err != nil {
+				return err
+			}
+			updatedAt = time.Unix(0, nanos)
+		}
+
+		tokens, result = take(tokens, updatedAt, now, limit)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, bucketKey, "tokens", tokens, "updated_at", now.UnixNano())
+			pipe.PExpire(ctx, bucketKey, result.Reset+time.Second)
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < redisMaxRetries; attempt++ {
+		err := s.client.Watch(ctx, takeToken, bucketKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		return result, err
+	}
+	return Result{}, ErrContention
+}
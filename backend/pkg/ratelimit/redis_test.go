@@ -0,0 +1,84 @@
+package ratelimit_test
+
+import (
+	"context"
+	"linkshortener/pkg/ratelimit"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisStore(t *testing.T) (*ratelimit.RedisStore, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return ratelimit.NewRedisStore(client), server
+}
+
+func TestRedisStoreTake(t *testing.T) {
+	store, server := newRedisStore(t)
+	limit := ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		result, err := store.Take(context.Background(), "key", limit)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !result.Allowed || result.Remaining != 1-i {
+			t.Fatalf("Expected request %d to be allowed with %d remaining, got %+v", i+1, 1-i, result)
+		}
+	}
+
+	result, err := store.Take(context.Background(), "key", limit)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Allowed {
+		t.Fatal("Expected request to be limited")
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > time.Hour {
+		t.Fatalf("Expected retry after within an hour, got %s", result.RetryAfter)
+	}
+
+	// Корзина удаляется, когда полностью пополнится
+	if ttl := server.TTL("linkshortener:ratelimit:key"); ttl <= 0 || ttl > 2*time.Hour+time.Second {
+		t.Fatalf("Expected bucket TTL up to the full refill time, got %s", ttl)
+	}
+}
+
+func TestRedisStoreConcurrentTakes(t *testing.T) {
+	store, _ := newRedisStore(t)
+	limit := ratelimit.Limit{Requests: 1, Period: time.Hour, Burst: 5}
+
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := store.Take(context.Background(), "key", limit)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result, err := store.Take(context.Background(), "key", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowed != 5 || result.Allowed {
+		t.Fatalf("Expected exactly the burst of 5 requests to pass, got %d and next allowed=%v", allowed, result.Allowed)
+	}
+}
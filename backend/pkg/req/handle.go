@@ -3,18 +3,23 @@ package req
 import (
 	"net/http"
 
+	"linkshortener/pkg/i18n"
 	"linkshortener/pkg/res"
 )
 
 func HandleBody[T any](w *http.ResponseWriter, r *http.Request) (*T, error) {
 	data, err := Decode[T](r.Body)
 	if err != nil {
-		res.Response(*w, 400, err.Error())
+		res.Error(*w, r, http.StatusBadRequest, res.CodeInvalidJSON)
 		return nil, err
 	}
 
 	if err := IsValid(data); err != nil {
-		res.Response(*w, 400, err.Error())
+		if fields, ok := FieldErrors(err, i18n.FromRequest(r)); ok {
+			res.ValidationError(*w, r, fields)
+		} else {
+			res.InternalError(*w, r, err)
+		}
 		return nil, err
 	}
 
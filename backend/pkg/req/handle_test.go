@@ -0,0 +1,87 @@
+package req_test
+
+import (
+	"encoding/json"
+	"linkshortener/pkg/req"
+	"linkshortener/pkg/res"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Name  string `json:"name" validate:"required,min=3"`
+}
+
+func TestHandleBodyFieldErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var w http.ResponseWriter = rec
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"not-an-email","name":"ab"}`))
+
+	if _, err := req.HandleBody[testRequest](&w, r); err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != res.ProblemContentType {
+		t.Fatalf("expected problem content type, got %q", got)
+	}
+
+	var problem res.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if problem.Code != res.CodeValidationFailed {
+		t.Fatalf("expected code %q, got %q", res.CodeValidationFailed, problem.Code)
+	}
+	if problem.Errors["email"] == "" || problem.Errors["name"] == "" {
+		t.Fatalf("expected errors keyed by JSON field names, got %v", problem.Errors)
+	}
+}
+
+func TestHandleBodyInvalidJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var w http.ResponseWriter = rec
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{`))
+
+	if _, err := req.HandleBody[testRequest](&w, r); err == nil {
+		t.Fatal("expected decode error")
+	}
+
+	var problem res.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if problem.Code != res.CodeInvalidJSON || problem.Status != http.StatusBadRequest {
+		t.Fatalf("expected invalid_json problem, got %+v", problem)
+	}
+}
+
+func TestHandleBodyLocalizedFieldErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var w http.ResponseWriter = rec
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"abc"}`))
+	r.Header.Set("Accept-Language", "ru-RU,ru;q=0.9")
+
+	if _, err := req.HandleBody[testRequest](&w, r); err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	var problem res.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if problem.Errors["email"] != "email обязательное поле" {
+		t.Fatalf("expected Russian field message, got %q", problem.Errors["email"])
+	}
+	if problem.Detail != res.Message("ru", res.CodeValidationFailed) {
+		t.Fatalf("expected Russian detail, got %q", problem.Detail)
+	}
+	if got := rec.Header().Get("Content-Language"); got != "ru" {
+		t.Fatalf("expected Content-Language ru, got %q", got)
+	}
+}
@@ -0,0 +1,29 @@
+package req
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Больший limit урезается: без потолка один запрос выгружал бы всю таблицу
+const MaxPageSize = 100
+
+var ErrInvalidPagination = errors.New("limit must be positive and offset must not be negative")
+
+// Разбирает limit и offset из query
+func Pagination(r *http.Request) (limit, offset uint, err error) {
+	rawLimit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		return 0, 0, err
+	}
+	rawOffset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil {
+		return 0, 0, err
+	}
+	// Отрицательное значение после приведения к uint стало бы огромным, и GORM снял бы ограничение совсем
+	if rawLimit <= 0 || rawOffset < 0 {
+		return 0, 0, ErrInvalidPagination
+	}
+	return uint(min(rawLimit, MaxPageSize)), uint(rawOffset), nil
+}
@@ -0,0 +1,36 @@
+package req_test
+
+import (
+	"errors"
+	"linkshortener/pkg/req"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	tests := []struct {
+		query                     string
+		wantLimit, wantOffset     uint
+		wantErr, wantInvalidRange bool
+	}{
+		{query: "limit=10&offset=20", wantLimit: 10, wantOffset: 20},
+		{query: "limit=100000&offset=0", wantLimit: req.MaxPageSize},
+		{query: "limit=-1&offset=0", wantErr: true, wantInvalidRange: true},
+		{query: "limit=0&offset=0", wantErr: true, wantInvalidRange: true},
+		{query: "limit=10&offset=-5", wantErr: true, wantInvalidRange: true},
+		{query: "limit=ten&offset=0", wantErr: true},
+		{query: "limit=10", wantErr: true},
+	}
+	for _, tt := range tests {
+		limit, offset, err := req.Pagination(httptest.NewRequest("GET", "/link?"+tt.query, nil))
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: unexpected error %v", tt.query, err)
+		}
+		if tt.wantInvalidRange && !errors.Is(err, req.ErrInvalidPagination) {
+			t.Fatalf("%s: expected ErrInvalidPagination, got %v", tt.query, err)
+		}
+		if limit != tt.wantLimit || offset != tt.wantOffset {
+			t.Fatalf("%s: expected %d/%d, got %d/%d", tt.query, tt.wantLimit, tt.wantOffset, limit, offset)
+		}
+	}
+}
@@ -1,11 +1,77 @@
 package req
 
 import (
+	"errors"
+	"linkshortener/pkg/i18n"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
+)
+
+var (
+	validate    = validator.New()
+	translators = ut.New(en.New(), en.New(), ru.New())
 )
 
+func init() {
+	// В ошибках используются имена полей из JSON, а не из Go-структур
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	registrations := map[string]func(*validator.Validate, ut.Translator) error{
+		i18n.English: enTranslations.RegisterDefaultTranslations,
+		i18n.Russian: ruTranslations.RegisterDefaultTranslations,
+	}
+	for language, register := range registrations {
+		translator, _ := translators.GetTranslator(language)
+		if err := register(validate, translator); err != nil {
+			panic("failed to register " + language + " validator translations: " + err.Error())
+		}
+	}
+}
+
 func IsValid[T any](payload T) error {
-	validate := validator.New()
-	err := validate.Struct(payload)
-	return err
+	return validate.Struct(payload)
+}
+
+// Переводит ошибки валидатора в сообщения по полям на языке клиента
+func FieldErrors(err error, language string) (map[string]string, bool) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil, false
+	}
+
+	translator, found := translators.GetTranslator(language)
+	if !found {
+		translator, _ = translators.GetTranslator(i18n.Default)
+	}
+
+	fields := make(map[string]string, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields[fieldName(fieldError)] = fieldError.Translate(translator)
+	}
+	return fields, true
+}
+
+// Для вложенных структур ключ — путь без имени корневого типа, например "address.city"
+func fieldName(fieldError validator.FieldError) string {
+	_, name, ok := strings.Cut(fieldError.Namespace(), ".")
+	if !ok {
+		return fieldError.Field()
+	}
+	return name
 }
@@ -0,0 +1,33 @@
+package res
+
+const (
+	CodeInvalidJSON             = "invalid_json"
+	CodeValidationFailed        = "validation_failed"
+	CodeInvalidID               = "invalid_id"
+	CodeInvalidPagination       = "invalid_pagination"
+	CodeInvalidDate             = "invalid_date"
+	CodeInvalidDateRange        = "invalid_date_range"
+	CodeInvalidGrouping         = "invalid_grouping"
+	CodeUnauthorized            = "unauthorized"
+	CodeForbidden               = "forbidden"
+	CodeInvalidCSRFToken        = "invalid_csrf_token"
+	CodeInvalidCredentials      = "invalid_credentials"
+	CodeInvalidRefreshToken     = "invalid_refresh_token"
+	CodeUserDisabled            = "user_disabled"
+	CodeUserExists              = "user_exists"
+	CodeUserNotFound            = "user_not_found"
+	CodeCannotChangeOwnAccount  = "cannot_change_own_account"
+	CodeLinkNotFound            = "link_not_found"
+	CodeLinkDisabled            = "link_disabled"
+	CodeHashTaken               = "hash_taken"
+	CodeCookieModeDisabled      = "cookie_mode_disabled"
+	CodeOIDCProviderNotFound    = "oidc_provider_not_found"
+	CodeOIDCProviderUnavailable = "oidc_provider_unavailable"
+	CodeOIDCStateMissing        = "oidc_state_missing"
+	CodeOIDCStateInvalid        = "oidc_state_invalid"
+	CodeOIDCLoginFailed         = "oidc_login_failed"
+	CodeRateLimited             = "rate_limited"
+	CodeRequestCanceled         = "request_canceled"
+	CodeTimeout                 = "timeout"
+	CodeInternal                = "internal_error"
+)
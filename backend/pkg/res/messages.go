@@ -0,0 +1,76 @@
+package res
+
+import "linkshortener/pkg/i18n"
+
+// Каталоги сообщений по кодам ошибок; в каждом каталоге должны быть все коды
+var catalogs = map[string]map[string]string{
+	i18n.English: {
+		CodeInvalidJSON:             "request body is not valid JSON",
+		CodeValidationFailed:        "request validation failed",
+		CodeInvalidID:               "id must be a positive integer",
+		CodeInvalidPagination:       "limit must be a positive integer and offset a non-negative integer",
+		CodeInvalidDate:             "date must be in YYYY-MM-DD format",
+		CodeInvalidDateRange:        "start date must be before end date",
+		CodeInvalidGrouping:         "by must be day or month",
+		CodeUnauthorized:            "authentication required",
+		CodeForbidden:               "access denied",
+		CodeInvalidCSRFToken:        "invalid csrf token",
+		CodeInvalidCredentials:      "invalid email or password",
+		CodeInvalidRefreshToken:     "invalid refresh token",
+		CodeUserDisabled:            "user is disabled",
+		CodeUserExists:              "user already exists",
+		CodeUserNotFound:            "user not found",
+		CodeCannotChangeOwnAccount:  "cannot change own account",
+		CodeLinkNotFound:            "link not found",
+		CodeLinkDisabled:            "link is disabled",
+		CodeHashTaken:               "hash is already taken",
+		CodeCookieModeDisabled:      "cookie mode is disabled",
+		CodeOIDCProviderNotFound:    "unknown oidc provider",
+		CodeOIDCProviderUnavailable: "oidc provider is unavailable",
+		CodeOIDCStateMissing:        "missing oidc state",
+		CodeOIDCStateInvalid:        "invalid oidc state",
+		CodeOIDCLoginFailed:         "oidc login failed",
+		CodeRateLimited:             "too many requests",
+		CodeRequestCanceled:         "request canceled by client",
+		CodeTimeout:                 "request timed out",
+		CodeInternal:                "internal server error",
+	},
+	i18n.Russian: {
+		CodeInvalidJSON:             "тело запроса не является корректным JSON",
+		CodeValidationFailed:        "запрос не прошёл проверку",
+		CodeInvalidID:               "id должен быть положительным целым числом",
+		CodeInvalidPagination:       "limit должен быть положительным целым числом, offset — неотрицательным",
+		CodeInvalidDate:             "дата должна быть в формате ГГГГ-ММ-ДД",
+		CodeInvalidDateRange:        "начальная дата должна быть раньше конечной",
+		CodeInvalidGrouping:         "by должен быть day или month",
+		CodeUnauthorized:            "требуется авторизация",
+		CodeForbidden:               "доступ запрещён",
+		CodeInvalidCSRFToken:        "неверный CSRF-токен",
+		CodeInvalidCredentials:      "неверный email или пароль",
+		CodeInvalidRefreshToken:     "неверный refresh-токен",
+		CodeUserDisabled:            "пользователь заблокирован",
+		CodeUserExists:              "пользователь уже существует",
+		CodeUserNotFound:            "пользователь не найден",
+		CodeCannotChangeOwnAccount:  "нельзя изменить собственную учётную запись",
+		CodeLinkNotFound:            "ссылка не найдена",
+		CodeLinkDisabled:            "ссылка отключена",
+		CodeHashTaken:               "такой hash уже занят",
+		CodeCookieModeDisabled:      "режим cookie отключён",
+		CodeOIDCProviderNotFound:    "неизвестный OIDC-провайдер",
+		CodeOIDCProviderUnavailable: "OIDC-провайдер недоступен",
+		CodeOIDCStateMissing:        "отсутствует состояние OIDC",
+		CodeOIDCStateInvalid:        "неверное состояние OIDC",
+		CodeOIDCLoginFailed:         "не удалось войти через OIDC",
+		CodeRateLimited:             "слишком много запросов",
+		CodeRequestCanceled:         "запрос отменён клиентом",
+		CodeTimeout:                 "превышено время выполнения запроса",
+		CodeInternal:                "внутренняя ошибка сервера",
+	},
+}
+
+func Message(language, code string) string {
+	if message, ok := catalogs[language][code]; ok {
+		return message
+	}
+	return catalogs[i18n.Default][code]
+}
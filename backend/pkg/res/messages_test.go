@@ -0,0 +1,24 @@
+package res
+
+import "testing"
+
+func TestCatalogsHaveSameCodes(t *testing.T) {
+	for language, catalog := range catalogs {
+		for otherLanguage, other := range catalogs {
+			for code := range other {
+				if catalog[code] == "" {
+					t.Errorf("code %q from %q catalog is missing in %q catalog", code, otherLanguage, language)
+				}
+			}
+		}
+	}
+}
+
+func TestMessageFallsBackToEnglish(t *testing.T) {
+	if got := Message("de", CodeLinkNotFound); got != "link not found" {
+		t.Fatalf("expected English fallback, got %q", got)
+	}
+	if got := Message("ru", CodeLinkNotFound); got != "ссылка не найдена" {
+		t.Fatalf("expected Russian message, got %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package res
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"linkshortener/pkg/i18n"
+	"linkshortener/pkg/logger"
+	"log/slog"
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Нестандартный статус nginx: клиент закрыл соединение, не дождавшись ответа
+const StatusClientClosedRequest = 499
+
+// Тело ошибки в формате RFC 7807 с машиночитаемым кодом
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+
+	Language string `json:"-"`
+}
+
+func Error(w http.ResponseWriter, r *http.Request, status int, code string) {
+	writeProblem(w, newProblem(r, status, code))
+}
+
+// Ошибки валидации по полям, ключи — имена полей в JSON
+func ValidationError(w http.ResponseWriter, r *http.Request, fields map[string]string) {
+	problem := newProblem(r, http.StatusBadRequest, CodeValidationFailed)
+	problem.Errors = fields
+	writeProblem(w, problem)
+}
+
+// Подробности внутренней ошибки пишутся только в лог, клиент получает общий ответ.
+// Отмена запроса клиентом отвечает 499, истёкший таймаут операции — 503.
+func InternalError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(r.Context().Err(), context.Canceled):
+		Error(w, r, StatusClientClosedRequest, CodeRequestCanceled)
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.WarnContext(r.Context(), "operation timed out",
+			slog.String("error", err.Error()),
+			slog.String("path", r.URL.Path),
+		)
+		Error(w, r, http.StatusServiceUnavailable, CodeTimeout)
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "internal error",
+		slog.String("error", err.Error()),
+		slog.String("path", r.URL.Path),
+	)
+	Error(w, r, http.StatusInternalServerError, CodeInternal)
+}
+
+func newProblem(r *http.Request, status int, code string) *Problem {
+	language := i18n.FromRequest(r)
+	return &Problem{
+		Language:  language,
+		Type:      "about:blank",
+		Title:     statusText(status),
+		Status:    status,
+		Detail:    Message(language, code),
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: logger.RequestID(r.Context()),
+	}
+}
+
+func statusText(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
+
+func writeProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("Content-Language", problem.Language)
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
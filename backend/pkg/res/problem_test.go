@@ -0,0 +1,54 @@
+package res_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"linkshortener/pkg/res"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInternalErrorMapsContextErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"canceled", fmt.Errorf("query: %w", context.Canceled), res.StatusClientClosedRequest, res.CodeRequestCanceled},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, res.CodeTimeout},
+		{"other", errors.New("boom"), http.StatusInternalServerError, res.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/link", nil)
+			w := httptest.NewRecorder()
+
+			res.InternalError(w, r, tt.err)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if body := w.Body.String(); !strings.Contains(body, `"code":"`+tt.code+`"`) {
+				t.Fatalf("expected code %q, got %s", tt.code, body)
+			}
+		})
+	}
+}
+
+func TestInternalErrorCanceledRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequestWithContext(ctx, http.MethodGet, "/link", nil)
+	w := httptest.NewRecorder()
+
+	res.InternalError(w, r, errors.New("driver: bad connection"))
+
+	if w.Code != res.StatusClientClosedRequest {
+		t.Fatalf("expected 499, got %d", w.Code)
+	}
+}
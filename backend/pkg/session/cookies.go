@@ -0,0 +1,105 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"linkshortener/config"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFCookie         = "csrf_token"
+	CSRFHeader         = "X-CSRF-Token"
+
+	refreshTokenPath = "/auth"
+	accessTokenTTL   = 2 * time.Hour
+	refreshTokenTTL  = 7 * 24 * time.Hour
+)
+
+// Устанавливает токены в HttpOnly cookie и возвращает новый CSRF-токен для double-submit проверки
+func SetTokens(w http.ResponseWriter, config *config.CookieConfig, accessToken, refreshToken string) string {
+	csrfToken := newCSRFToken()
+
+	http.SetCookie(w, newCookie(config, AccessTokenCookie, accessToken, "/", accessTokenTTL, true))
+	http.SetCookie(w, newCookie(config, RefreshTokenCookie, refreshToken, refreshTokenPath, refreshTokenTTL, true))
+	http.SetCookie(w, newCookie(config, CSRFCookie, csrfToken, "/", refreshTokenTTL, false))
+
+	return csrfToken
+}
+
+func Clear(w http.ResponseWriter, config *config.CookieConfig) {
+	http.SetCookie(w, newCookie(config, AccessTokenCookie, "", "/", -1, true))
+	http.SetCookie(w, newCookie(config, RefreshTokenCookie, "", refreshTokenPath, -1, true))
+	http.SetCookie(w, newCookie(config, CSRFCookie, "", "/", -1, false))
+}
+
+// Короткоживущая HttpOnly cookie процесса входа (state и PKCE для OIDC) с флагами из настроек cookie.
+// Возврат от провайдера — межсайтовая навигация, при SameSite=Strict браузер не отправил бы cookie,
+// поэтому Strict понижается до Lax
+func SetFlowCookie(w http.ResponseWriter, config *config.CookieConfig, name, value, path string, ttl time.Duration) {
+	cookie := newCookie(config, name, value, path, ttl, true)
+	if cookie.SameSite == http.SameSiteStrictMode {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	http.SetCookie(w, cookie)
+}
+
+func ClearFlowCookie(w http.ResponseWriter, config *config.CookieConfig, name, path string) {
+	SetFlowCookie(w, config, name, "", path, -1)
+}
+
+func IsSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// Заголовок X-CSRF-Token должен совпадать со значением cookie csrf_token
+func ValidCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) == 1
+}
+
+func newCookie(config *config.CookieConfig, name, value, path string, ttl time.Duration, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   config.Domain,
+		HttpOnly: httpOnly,
+		Secure:   config.Secure,
+		SameSite: sameSite(config.SameSite),
+	}
+	if ttl < 0 {
+		cookie.MaxAge = -1
+	} else {
+		cookie.MaxAge = int(ttl.Seconds())
+	}
+	return cookie
+}
+
+func sameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func newCSRFToken() string {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		panic("failed to generate csrf token: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,87 @@
+package session_test
+
+import (
+	"linkshortener/config"
+	"linkshortener/pkg/session"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetTokens(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	csrfToken := session.SetTokens(recorder, &config.CookieConfig{Secure: true}, "access", "refresh")
+
+	if csrfToken == "" {
+		t.Fatal("expected csrf token")
+	}
+
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range recorder.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	for _, name := range []string{session.AccessTokenCookie, session.RefreshTokenCookie} {
+		cookie, ok := cookies[name]
+		if !ok {
+			t.Fatalf("expected %s cookie", name)
+		}
+		if !cookie.HttpOnly || !cookie.Secure {
+			t.Fatalf("expected %s cookie to be HttpOnly and Secure", name)
+		}
+	}
+
+	if cookies[session.CSRFCookie].HttpOnly {
+		t.Fatal("expected csrf cookie to be readable by JavaScript")
+	}
+
+	if cookies[session.CSRFCookie].Value != csrfToken {
+		t.Fatalf("expected csrf cookie %s, got %s", csrfToken, cookies[session.CSRFCookie].Value)
+	}
+}
+
+func TestValidCSRF(t *testing.T) {
+	testCases := []struct {
+		cookie   string
+		header   string
+		expected bool
+	}{
+		{cookie: "token", header: "token", expected: true},
+		{cookie: "token", header: "other", expected: false},
+		{cookie: "token", header: "", expected: false},
+		{cookie: "", header: "", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		r := httptest.NewRequest(http.MethodPost, "/link", nil)
+		if testCase.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: session.CSRFCookie, Value: testCase.cookie})
+		}
+		if testCase.header != "" {
+			r.Header.Set(session.CSRFHeader, testCase.header)
+		}
+
+		if session.ValidCSRF(r) != testCase.expected {
+			t.Fatalf("expected %v for cookie %q and header %q", testCase.expected, testCase.cookie, testCase.header)
+		}
+	}
+}
+
+func TestSetFlowCookie(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	session.SetFlowCookie(recorder, &config.CookieConfig{Secure: true, SameSite: "strict"}, "oidc_state", "state", "/auth/oidc/", time.Minute)
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected 1 cookie, got %d", len(cookies))
+	}
+	cookie := cookies[0]
+	if !cookie.HttpOnly || !cookie.Secure || cookie.MaxAge != 60 {
+		t.Fatalf("expected HttpOnly Secure cookie for 60s, got %+v", cookie)
+	}
+	// Strict не пережил бы возврат от провайдера
+	if cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("expected SameSite=Lax, got %v", cookie.SameSite)
+	}
+}
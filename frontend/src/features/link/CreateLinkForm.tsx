@@ -25,7 +25,7 @@ export const CreateLinkForm: React.FC<CreateLinkFormProps> = ({ onLinkCreated })
       setSuccess('Ссылка успешно создана!')
       setTimeout(() => setSuccess(''), 3000)
     } catch (error: any) {
-      setError(error.response?.data?.message || 'Ошибка создания ссылки')
+      setError(error.response?.data?.detail || 'Ошибка создания ссылки')
     } finally {
       setLoading(false)
     }
@@ -22,7 +22,7 @@ export const LoginPage: React.FC = () => {
       login(response.access_token, response.refresh_token)
       navigate('/')
     } catch (error: any) {
-      setError(error.response?.data?.message || 'Ошибка входа')
+      setError(error.response?.data?.detail || 'Ошибка входа')
     } finally {
       setLoading(false)
     }
@@ -23,7 +23,7 @@ export const RegisterPage: React.FC = () => {
       setSuccess('Регистрация успешна! Теперь вы можете войти')
       setTimeout(() => navigate('/login'), 2000)
     } catch (error: any) {
-      setError(error.response?.data?.message || 'Ошибка регистрации')
+      setError(error.response?.data?.detail || 'Ошибка регистрации')
     } finally {
       setLoading(false)
     }
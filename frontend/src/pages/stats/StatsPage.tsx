@@ -28,7 +28,7 @@ export const StatsPage: React.FC = () => {
       const response = await statsApi.getStats(fromDate, toDate, groupBy)
       setStats(response)
     } catch (error: any) {
-      setError(error.response?.data?.message || 'Ошибка загрузки статистики')
+      setError(error.response?.data?.detail || 'Ошибка загрузки статистики')
     } finally {
       setLoading(false)
     }